@@ -0,0 +1,33 @@
+package atomicval
+
+// Bool is a [Value] for booleans, adding operations for flipping and setting
+// flags without hand-written compare-and-swap loops.
+//
+// The embedded field is named Value, which hides [Value.Value], so a *Bool
+// implements [database/sql.Scanner] but not [database/sql/driver.Valuer]. To
+// write one to a database, pass &b.Value instead.
+//
+// The zero Bool is ready for use, and holds false. Must not be copied after
+// first use.
+type Bool struct {
+	Value[bool]
+}
+
+// Toggle atomically flips the value (false, if unset), returning the new one.
+func (b *Bool) Toggle() (new bool) {
+	return b.UpdateAndGet(func(old bool) bool { return !old })
+}
+
+// SetTrue stores true, reporting whether the value was previously false (or
+// unset).
+func (b *Bool) SetTrue() (changed bool) {
+	// since CompareAndSwap compares until it observes a mismatch, a failure
+	// means the value was already true
+	return b.CompareAndSwap(false, true)
+}
+
+// SetFalse stores false, reporting whether the value was previously true. An
+// unset Bool is left unset, since it already holds false.
+func (b *Bool) SetFalse() (changed bool) {
+	return b.CompareAndSwap(true, false)
+}
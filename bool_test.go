@@ -0,0 +1,58 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBool(t *testing.T) {
+	var b Bool
+	requireEqual(t, false, b.Load())
+	requireEqual(t, true, b.Toggle())
+	requireEqual(t, false, b.Toggle())
+	requireEqual(t, false, b.Load())
+
+	requireEqual(t, true, b.SetTrue())
+	requireEqual(t, false, b.SetTrue())
+	requireEqual(t, true, b.Load())
+	requireEqual(t, true, b.SetFalse())
+	requireEqual(t, false, b.SetFalse())
+	requireEqual(t, false, b.Load())
+
+	// unset counts as false
+	var u Bool
+	requireEqual(t, false, u.SetFalse())
+	requireEqual(t, false, u.IsSet())
+	requireEqual(t, true, u.SetTrue())
+
+	t.Run("concurrent", func(t *testing.T) {
+		iters := 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		for _, n := range []int{4 * runtime.GOMAXPROCS(0), 4*runtime.GOMAXPROCS(0) + 1} {
+			var toggled, flag Bool
+			var changes atomic.Int32
+			var wg sync.WaitGroup
+			for range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					toggled.Toggle()
+					for range iters {
+						if flag.SetTrue() {
+							changes.Add(1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			requireEqual(t, n%2 == 1, toggled.Load())
+			requireEqual(t, int32(1), changes.Load())
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package atomicval
+
+import (
+	"slices"
+	"sync/atomic"
+	"unsafe"
+)
+
+// CompValue is like [Value], but accepts any T, including slices, maps and
+// funcs, which the comparable constraint rules out. Since == may not be
+// available, CompareAndSwap uses the equality function given to
+// [NewCompValue] instead.
+//
+// The equality function must be consistent: it should report whether two
+// values are equivalent, be symmetric, and give the same answer for the same
+// inputs every time it is called. It must not retain or modify its arguments.
+//
+// The zero CompValue has no equality function; Load, Store and Swap work as
+// usual, but CompareAndSwap always returns false. Must not be copied after
+// first use.
+type CompValue[T any] struct {
+	_ noCopy
+
+	// prevent unruly type conversions (see [atomic.Pointer])
+	_ [0]*T
+
+	v  unsafe.Pointer
+	eq func(a, b T) bool
+}
+
+// NewCompValue returns an unset [CompValue] which compares values using eq.
+func NewCompValue[T any](eq func(a, b T) bool) *CompValue[T] {
+	return &CompValue[T]{eq: eq}
+}
+
+// Load returns the value set by the most recent Store. Returns the zero value
+// if no value has been set.
+func (c *CompValue[T]) Load() (val T) {
+	dp := atomic.LoadPointer(&c.v)
+	if dp == nil {
+		return val
+	}
+
+	return *(*T)(dp)
+}
+
+// Store sets the value of the [CompValue] c to val.
+func (c *CompValue[T]) Store(val T) {
+	atomic.StorePointer(&c.v, unsafe.Pointer(&[1]T{val}))
+}
+
+// Swap stores new and returns the previous value. Returns the zero value if no
+// value has been set.
+func (c *CompValue[T]) Swap(new T) (old T) {
+	dp := atomic.SwapPointer(&c.v, unsafe.Pointer(&[1]T{new}))
+	if dp == nil {
+		return old
+	}
+
+	return *(*T)(dp)
+}
+
+// CompareAndSwap stores new if the current value is equal to old according to
+// the equality function, reporting whether it did so. If no value has been
+// set, old is compared against the zero value for type T. The equality
+// function is always passed the current value first.
+//
+// As with [Value.CompareAndSwap], if the value is replaced concurrently, the
+// new value is compared again, so the equality function may be called more
+// than once.
+func (c *CompValue[T]) CompareAndSwap(old, new T) (swapped bool) {
+	if c.eq == nil {
+		return false
+	}
+
+	return c.compareAndSwap(old, new, c.eq)
+}
+
+func (c *CompValue[T]) compareAndSwap(old, new T, eq func(a, b T) bool) (swapped bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		var cur T
+		dp := atomic.LoadPointer(&c.v)
+		if dp != nil {
+			cur = *(*T)(dp)
+		}
+
+		if !eq(cur, old) {
+			return false
+		}
+
+		if box == nil {
+			box = unsafe.Pointer(&[1]T{new})
+		}
+
+		// as in [Value.CompareAndSwap], compare again if the value was
+		// replaced in the meantime
+		if atomic.CompareAndSwapPointer(&c.v, dp, box) {
+			return true
+		}
+	}
+}
+
+// CompareAndSwapSlice is like [CompValue.CompareAndSwap], but compares the
+// current slice against old elementwise with [slices.Equal], regardless of the
+// CompValue's own equality function (and even if it has none). The comparison
+// neither allocates nor uses reflection.
+//
+// As with slices.Equal, a nil slice and an empty non-nil slice are equal.
+// In particular, an unset CompValue holds a nil slice, so old may be either
+// to swap in a first value.
+func CompareAndSwapSlice[E comparable](c *CompValue[[]E], old, new []E) (swapped bool) {
+	return c.compareAndSwap(old, new, slices.Equal[[]E])
+}
+
+// AppendCOW atomically appends items to the slice held by c, returning the
+// new slice. The current slice is never modified: each append copies it into
+// a newly allocated one, which is then published, so readers holding the
+// previous slice keep seeing it unchanged. If c is modified concurrently, the
+// copy is made again from the new slice, so no append is lost.
+//
+// Every attempt copies the whole slice, so an append costs O(n) in its length,
+// and under contention that cost is paid again for each retry. The comparator
+// of c is not used.
+func AppendCOW[E any](c *CompValue[[]E], items ...E) []E {
+	for {
+		dp := atomic.LoadPointer(&c.v)
+		var cur []E
+		if dp != nil {
+			cur = *(*[]E)(dp)
+		}
+
+		s := make([]E, len(cur), len(cur)+len(items))
+		copy(s, cur)
+		s = append(s, items...)
+
+		if atomic.CompareAndSwapPointer(&c.v, dp, unsafe.Pointer(&[1][]E{s})) {
+			return s
+		}
+	}
+}
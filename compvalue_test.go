@@ -0,0 +1,213 @@
+package atomicval
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestCompValue(t *testing.T) {
+	t.Run("bytes", func(t *testing.T) {
+		a := NewCompValue(bytes.Equal)
+		requireEqual(t, 0, len(a.Load()))
+
+		// unset compares as the zero value, which bytes.Equal equates with empty
+		requireEqual(t, true, a.CompareAndSwap([]byte{}, []byte("a")))
+		requireEqual(t, "a", string(a.Load()))
+
+		a.Store([]byte("b"))
+		requireEqual(t, "b", string(a.Swap([]byte("c"))))
+
+		// compared by contents, not identity
+		requireEqual(t, false, a.CompareAndSwap([]byte("b"), []byte("d")))
+		requireEqual(t, true, a.CompareAndSwap([]byte("c"), []byte("d")))
+		requireEqual(t, "d", string(a.Load()))
+	})
+
+	t.Run("map", func(t *testing.T) {
+		a := NewCompValue(maps.Equal[map[string]int])
+		requireEqual(t, true, a.CompareAndSwap(nil, map[string]int{"x": 1}))
+		requireEqual(t, false, a.CompareAndSwap(map[string]int{"x": 2}, nil))
+		requireEqual(t, true, a.CompareAndSwap(map[string]int{"x": 1}, map[string]int{"x": 1, "y": 2}))
+		requireEqual(t, 2, a.Load()["y"])
+
+		old := a.Swap(nil)
+		requireEqual(t, 2, len(old))
+		requireEqual(t, 0, len(a.Load()))
+	})
+
+	t.Run("comparator argument order", func(t *testing.T) {
+		var calls [][2]int
+		a := NewCompValue(func(cur, old int) bool {
+			calls = append(calls, [2]int{cur, old})
+			return cur == old
+		})
+		a.Store(1)
+		a.CompareAndSwap(2, 3)
+		requireEqual(t, [2]int{1, 2}, calls[0])
+	})
+
+	t.Run("replaced by an equal value", func(t *testing.T) {
+		// the first comparison is made stale by a store of equal contents, so
+		// the swap is retried against the new value, and succeeds
+		var a *CompValue[[]byte]
+		calls := 0
+		a = NewCompValue(func(cur, old []byte) bool {
+			if calls++; calls == 1 {
+				a.Store([]byte("a"))
+			}
+			return bytes.Equal(cur, old)
+		})
+		a.Store([]byte("a"))
+
+		requireEqual(t, true, a.CompareAndSwap([]byte("a"), []byte("b")))
+		requireEqual(t, 2, calls)
+		requireEqual(t, "b", string(a.Load()))
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var a CompValue[func()]
+		requireEqual(t, true, a.Load() == nil)
+		a.Store(func() {})
+		requireEqual(t, true, a.Swap(nil) != nil)
+		requireEqual(t, false, a.CompareAndSwap(nil, nil))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 500
+		if testing.Short() {
+			iters = 50
+		}
+
+		a := NewCompValue(func(a, b []int) bool { return len(a) == len(b) })
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					for {
+						old := a.Load()
+						if a.CompareAndSwap(old, append(old[:len(old):len(old)], 0)) {
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, n*iters, len(a.Load()))
+	})
+}
+
+func TestCompareAndSwapSlice(t *testing.T) {
+	var a CompValue[[]string] // no comparator needed
+	requireEqual(t, false, CompareAndSwapSlice(&a, []string{"x"}, []string{"a"}))
+
+	// unset holds nil, which equals empty
+	requireEqual(t, true, CompareAndSwapSlice(&a, []string{}, []string{"a"}))
+	requireEqual(t, "[a]", fmt.Sprint(a.Load()))
+
+	// compared by contents
+	requireEqual(t, false, CompareAndSwapSlice(&a, []string{"b"}, nil))
+	requireEqual(t, false, CompareAndSwapSlice(&a, []string{"a", "a"}, nil))
+	requireEqual(t, false, CompareAndSwapSlice(&a, nil, nil))
+	requireEqual(t, true, CompareAndSwapSlice(&a, []string{"a"}, []string{}))
+
+	// empty equals nil, both ways
+	requireEqual(t, true, CompareAndSwapSlice(&a, nil, nil))
+	requireEqual(t, true, a.Load() == nil)
+	requireEqual(t, true, CompareAndSwapSlice(&a, []string{}, []string{"b"}))
+
+	// the CompValue's own comparator is not consulted
+	b := NewCompValue(func(_, _ []int) bool { return true })
+	b.Store([]int{1})
+	requireEqual(t, false, CompareAndSwapSlice(b, []int{2}, nil))
+	requireEqual(t, true, CompareAndSwapSlice(b, []int{1}, nil))
+
+	t.Run("allocations", func(t *testing.T) {
+		var c CompValue[[]int]
+		c.Store([]int{1, 2, 3})
+		old := []int{1, 2, 4}
+		requireEqual(t, 0.0, testing.AllocsPerRun(100, func() { CompareAndSwapSlice(&c, old, nil) }))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 200
+		if testing.Short() {
+			iters = 20
+		}
+
+		// each goroutine appends copies of the slice it loaded; an append
+		// only lands if nobody else's did in the meantime
+		var c CompValue[[]int]
+		var wg sync.WaitGroup
+		for g := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					for {
+						old := c.Load()
+						if CompareAndSwapSlice(&c, slices.Clone(old), append(slices.Clip(old), g)) {
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		counts := make([]int, n)
+		for _, g := range c.Load() {
+			counts[g]++
+		}
+		for _, count := range counts {
+			requireEqual(t, iters, count)
+		}
+	})
+}
+
+func TestAppendCOW(t *testing.T) {
+	var a CompValue[[]int]
+	requireEqual(t, "[1 2]", fmt.Sprint(AppendCOW(&a, 1, 2)))
+	before := a.Load()
+	requireEqual(t, "[1 2 3]", fmt.Sprint(AppendCOW(&a, 3)))
+	requireEqual(t, "[1 2 3]", fmt.Sprint(a.Load()))
+
+	// readers of the previous slice are unaffected
+	requireEqual(t, "[1 2]", fmt.Sprint(before))
+	AppendCOW(&a) // even with nothing to append, a copy is published
+	requireEqual(t, "[1 2 3]", fmt.Sprint(a.Load()))
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 200
+		if testing.Short() {
+			iters = 20
+		}
+
+		var c CompValue[[]int]
+		var wg sync.WaitGroup
+		for g := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range iters {
+					AppendCOW(&c, g*iters+i)
+				}
+			}()
+		}
+		wg.Wait()
+
+		got := slices.Sorted(slices.Values(c.Load()))
+		requireEqual(t, n*iters, len(got))
+		for i, v := range got {
+			requireEqual(t, i, v)
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package atomicval
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Encoding methods operate on a single point-in-time snapshot of the value, as
+// returned by one [Value.Load], and decoding methods store the decoded value
+// with a single [Value.Store]. They use pointer receivers since a Value must
+// not be copied.
+
+// MarshalJSON implements [json.Marshaler], encoding the current value as if it
+// were a plain T. An unset Value is encoded as null.
+func (v *Value[T]) MarshalJSON() ([]byte, error) {
+	val, ok := v.LoadOption().Get()
+	if !ok {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(val)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], decoding data as a T and storing
+// it. A JSON null resets the Value to its unset state, even if T (e.g. a pointer
+// or interface type) could otherwise represent null.
+func (v *Value[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		v.Reset()
+		return nil
+	}
+
+	var val T
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+
+	v.Store(val)
+	return nil
+}
+
+// IsZero reports whether the Value is unset, the opposite of [Value.IsSet].
+// encoding/json uses it for fields tagged omitzero (as of Go 1.24), so that
+// unset Values are omitted, while ones explicitly holding the zero value of T
+// are encoded.
+func (v *Value[T]) IsZero() bool {
+	return !v.IsSet()
+}
+
+// MarshalText implements [encoding.TextMarshaler] by delegating to the current
+// value, if T (or *T) implements it. An unset Value is encoded as empty text.
+// Returns an error if T does not implement [encoding.TextMarshaler].
+func (v *Value[T]) MarshalText() ([]byte, error) {
+	val, ok := v.LoadOption().Get()
+
+	m, isMarshaler := any(val).(encoding.TextMarshaler)
+	if !isMarshaler {
+		m, isMarshaler = any(&val).(encoding.TextMarshaler)
+	}
+
+	if !isMarshaler {
+		return nil, fmt.Errorf("atomicval: %s does not implement encoding.TextMarshaler", reflect.TypeFor[T]())
+	}
+
+	if !ok {
+		return []byte{}, nil
+	}
+
+	return m.MarshalText()
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler] by decoding text with
+// *T's UnmarshalText method and storing the result. Empty text resets the Value
+// to its unset state. Returns an error, leaving the Value unchanged, if *T does
+// not implement [encoding.TextUnmarshaler].
+func (v *Value[T]) UnmarshalText(text []byte) error {
+	var val T
+	u, ok := any(&val).(encoding.TextUnmarshaler)
+	if !ok {
+		return fmt.Errorf("atomicval: %s does not implement encoding.TextUnmarshaler", reflect.PointerTo(reflect.TypeFor[T]()))
+	}
+
+	if len(text) == 0 {
+		v.Reset()
+		return nil
+	}
+
+	if err := u.UnmarshalText(text); err != nil {
+		return err
+	}
+
+	v.Store(val)
+	return nil
+}
+
+// Gob encodings start with a presence byte, so that decoding can distinguish an
+// unset Value from one holding the zero value.
+const (
+	gobUnset byte = iota
+	gobSet
+)
+
+// GobEncode implements [gob.GobEncoder], encoding whether the Value is set
+// followed by the gob encoding of the current value, if any. The usual
+// restrictions of [encoding/gob] apply to T; e.g. interface types require the
+// dynamic types to be registered with [gob.Register]. Unlike other zero fields,
+// an unset Value which is a struct field is still encoded, so decoding resets
+// the corresponding destination field to unset.
+func (v *Value[T]) GobEncode() ([]byte, error) {
+	val, ok := v.LoadOption().Get()
+	if !ok {
+		return []byte{gobUnset}, nil
+	}
+
+	buf := bytes.NewBuffer([]byte{gobSet})
+	if err := gob.NewEncoder(buf).Encode(&val); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements [gob.GobDecoder], decoding data produced by
+// [Value.GobEncode] and storing the result, or resetting the Value if the
+// encoded Value was unset.
+func (v *Value[T]) GobDecode(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("atomicval: empty gob data")
+	}
+
+	switch data[0] {
+	case gobUnset:
+		if len(data) != 1 {
+			return errors.New("atomicval: unexpected gob data after unset marker")
+		}
+
+		v.Reset()
+		return nil
+
+	case gobSet:
+		var val T
+		if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&val); err != nil {
+			return err
+		}
+
+		v.Store(val)
+		return nil
+
+	default:
+		return fmt.Errorf("atomicval: invalid gob presence byte %#x", data[0])
+	}
+}
+
+// Binary encodings likewise start with a presence byte, followed by the raw
+// in-memory representation of the value, if any.
+const (
+	binaryUnset byte = iota
+	binarySet
+)
+
+// MarshalBinary implements [encoding.BinaryMarshaler] for pointer-free T
+// (numbers, booleans, and arrays and structs of these), encoding whether the
+// Value is set followed by the raw bytes of the current value, if any. This is
+// much cheaper than [Value.GobEncode], but the encoding reflects T's in-memory
+// layout and byte order, so it can only be decoded into the same T on the same
+// architecture. Returns an error if T may contain pointers (including strings,
+// slices, maps and interfaces), whose raw bytes would be meaningless elsewhere.
+func (v *Value[T]) MarshalBinary() ([]byte, error) {
+	if err := checkPointerFree[T](); err != nil {
+		return nil, err
+	}
+
+	p := v.LoadPtr()
+	if p == nil {
+		return []byte{binaryUnset}, nil
+	}
+
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(p)), unsafe.Sizeof(*p))
+	return append([]byte{binarySet}, raw...), nil
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], decoding data
+// produced by [Value.MarshalBinary] and storing the result, or resetting the
+// Value if the encoded Value was unset. Returns an error, leaving the Value
+// unchanged, if data is malformed or T may contain pointers.
+func (v *Value[T]) UnmarshalBinary(data []byte) error {
+	if err := checkPointerFree[T](); err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return errors.New("atomicval: empty binary data")
+	}
+
+	switch data[0] {
+	case binaryUnset:
+		if len(data) != 1 {
+			return errors.New("atomicval: unexpected binary data after unset marker")
+		}
+
+		v.Reset()
+		return nil
+
+	case binarySet:
+		var val T
+		raw := unsafe.Slice((*byte)(unsafe.Pointer(&val)), unsafe.Sizeof(val))
+		if len(data)-1 != len(raw) {
+			return fmt.Errorf("atomicval: binary data for %s has %d bytes, want %d", reflect.TypeFor[T](), len(data)-1, len(raw))
+		}
+
+		copy(raw, data[1:])
+		v.Store(val)
+		return nil
+
+	default:
+		return fmt.Errorf("atomicval: invalid binary presence byte %#x", data[0])
+	}
+}
+
+// checkPointerFree returns an error if values of type T may contain pointers.
+func checkPointerFree[T any]() error {
+	if t := reflect.TypeFor[T](); !pointerFree(t) {
+		return fmt.Errorf("atomicval: %s may contain pointers, cannot use raw binary encoding", t)
+	}
+
+	return nil
+}
+
+func pointerFree(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+
+	case reflect.Array:
+		return t.Len() == 0 || pointerFree(t.Elem())
+
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if !pointerFree(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return false
+	}
+}
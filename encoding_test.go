@@ -0,0 +1,339 @@
+package atomicval
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValue_JSON(t *testing.T) {
+	t.Run("scalar", func(t *testing.T) {
+		var a Value[int]
+		b, err := json.Marshal(&a)
+		requireZero(t, err)
+		requireEqual(t, "null", string(b))
+
+		a.Store(0)
+		b, err = json.Marshal(&a)
+		requireZero(t, err)
+		requireEqual(t, "0", string(b))
+
+		var c Value[int]
+		requireZero(t, json.Unmarshal([]byte("42"), &c))
+		requireEqual(t, 42, c.Load())
+
+		requireZero(t, json.Unmarshal([]byte(" null "), &c))
+		requireEqual(t, false, c.IsSet())
+
+		requireNotZero(t, json.Unmarshal([]byte(`"42"`), &c))
+		requireEqual(t, false, c.IsSet())
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		type config struct {
+			Name  string
+			Limit int `json:"limit"`
+		}
+
+		type wrapper struct {
+			Config Value[config]
+			Unset  Value[config]
+		}
+
+		var src wrapper
+		src.Config.Store(config{Name: "a", Limit: 3})
+
+		b, err := json.Marshal(&src)
+		requireZero(t, err)
+		requireEqual(t, `{"Config":{"Name":"a","limit":3},"Unset":null}`, string(b))
+
+		var dst wrapper
+		requireZero(t, json.Unmarshal(b, &dst))
+		requireEqual(t, config{Name: "a", Limit: 3}, dst.Config.Load())
+		requireEqual(t, false, dst.Unset.IsSet())
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		n := 7
+		var a Value[*int]
+		a.Store(&n)
+
+		b, err := json.Marshal(&a)
+		requireZero(t, err)
+		requireEqual(t, "7", string(b))
+
+		var c Value[*int]
+		requireZero(t, json.Unmarshal(b, &c))
+		requireEqual(t, 7, *c.Load())
+
+		// a nil pointer round-trips as unset
+		a.Store(nil)
+		b, err = json.Marshal(&a)
+		requireZero(t, err)
+		requireEqual(t, "null", string(b))
+
+		requireZero(t, json.Unmarshal(b, &c))
+		requireEqual(t, false, c.IsSet())
+	})
+}
+
+// verbosity is a stringy type with text methods, as commonly used for flags.
+type verbosity string
+
+func (vb verbosity) MarshalText() ([]byte, error) { return []byte(strings.ToUpper(string(vb))), nil }
+
+func (vb *verbosity) UnmarshalText(text []byte) error {
+	switch s := strings.ToLower(string(text)); s {
+	case "debug", "info":
+		*vb = verbosity(s)
+		return nil
+	default:
+		return errors.New("unknown verbosity")
+	}
+}
+
+func TestValue_Text(t *testing.T) {
+	var a Value[verbosity]
+	b, err := a.MarshalText()
+	requireZero(t, err)
+	requireEqual(t, 0, len(b))
+
+	requireZero(t, a.UnmarshalText([]byte("Debug")))
+	requireEqual(t, verbosity("debug"), a.Load())
+
+	b, err = a.MarshalText()
+	requireZero(t, err)
+	requireEqual(t, "DEBUG", string(b))
+
+	requireNotZero(t, a.UnmarshalText([]byte("loud")))
+	requireEqual(t, verbosity("debug"), a.Load())
+
+	requireZero(t, a.UnmarshalText(nil))
+	requireEqual(t, false, a.IsSet())
+
+	t.Run("time", func(t *testing.T) {
+		var ts Value[time.Time]
+		requireZero(t, ts.UnmarshalText([]byte("2024-01-02T03:04:05Z")))
+		requireEqual(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ts.Load())
+
+		b, err := ts.MarshalText()
+		requireZero(t, err)
+		requireEqual(t, "2024-01-02T03:04:05Z", string(b))
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		var def, lvl Value[verbosity]
+		def.Store("info")
+
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.TextVar(&lvl, "level", &def, "log level")
+		requireEqual(t, verbosity("info"), lvl.Load())
+
+		requireZero(t, fs.Parse([]string{"-level", "DEBUG"}))
+		requireEqual(t, verbosity("debug"), lvl.Load())
+	})
+
+	t.Run("query", func(t *testing.T) {
+		var lvl Value[verbosity]
+		q, err := url.ParseQuery("level=info")
+		requireZero(t, err)
+		requireZero(t, lvl.UnmarshalText([]byte(q.Get("level"))))
+		requireEqual(t, verbosity("info"), lvl.Load())
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		var n Value[int]
+		_, err := n.MarshalText()
+		requireEqual(t, "atomicval: int does not implement encoding.TextMarshaler", err.Error())
+
+		n.Store(1)
+		err = n.UnmarshalText([]byte("2"))
+		requireEqual(t, "atomicval: *int does not implement encoding.TextUnmarshaler", err.Error())
+		requireEqual(t, 1, n.Load())
+	})
+
+	t.Run("json unaffected", func(t *testing.T) {
+		var a Value[verbosity]
+		a.Store("info")
+		b, err := json.Marshal(&a)
+		requireZero(t, err)
+		requireEqual(t, `"INFO"`, string(b))
+	})
+}
+
+func TestValue_Gob(t *testing.T) {
+	roundTrip := func(t *testing.T, src *Value[int]) *Value[int] {
+		t.Helper()
+		b, err := src.GobEncode()
+		requireZero(t, err)
+
+		dst := new(Value[int])
+		dst.Store(-1) // should be overwritten or reset
+		requireZero(t, dst.GobDecode(b))
+		return dst
+	}
+
+	var a Value[int]
+	requireEqual(t, false, roundTrip(t, &a).IsSet())
+
+	a.Store(0)
+	dst := roundTrip(t, &a)
+	requireEqual(t, true, dst.IsSet())
+	requireEqual(t, 0, dst.Load())
+
+	a.Store(42)
+	dst = roundTrip(t, &a)
+	requireEqual(t, true, dst.IsSet())
+	requireEqual(t, 42, dst.Load())
+
+	t.Run("struct fields", func(t *testing.T) {
+		type point struct{ X, Y int }
+		type message struct {
+			Name   string
+			Point  Value[point]
+			Zero   Value[point]
+			Absent Value[point]
+		}
+
+		var src message
+		src.Name = "m"
+		src.Point.Store(point{X: 1, Y: 2})
+		src.Zero.Store(point{})
+
+		var buf bytes.Buffer
+		requireZero(t, gob.NewEncoder(&buf).Encode(&src))
+
+		var dst message
+		requireZero(t, gob.NewDecoder(&buf).Decode(&dst))
+		requireEqual(t, "m", dst.Name)
+		requireEqual(t, point{X: 1, Y: 2}, dst.Point.Load())
+		requireEqual(t, true, dst.Zero.IsSet())
+		requireEqual(t, false, dst.Absent.IsSet())
+
+		// an unset field is encoded too, resetting the destination field
+		buf.Reset()
+		requireZero(t, gob.NewEncoder(&buf).Encode(&src))
+		var pre message
+		pre.Name = "pre"
+		pre.Absent.Store(point{X: 5})
+		requireZero(t, gob.NewDecoder(&buf).Decode(&pre))
+		requireEqual(t, "m", pre.Name)
+		requireEqual(t, false, pre.Absent.IsSet())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var v Value[int]
+		v.Store(1)
+		requireNotZero(t, v.GobDecode(nil))
+		requireNotZero(t, v.GobDecode([]byte{gobUnset, 0}))
+		requireNotZero(t, v.GobDecode([]byte{7}))
+		requireNotZero(t, v.GobDecode([]byte{gobSet, 0xff}))
+		requireEqual(t, 1, v.Load())
+	})
+}
+
+func TestValue_Binary(t *testing.T) {
+	type hash [32]byte
+
+	roundTrip := func(t *testing.T, src *Value[hash]) *Value[hash] {
+		t.Helper()
+		b, err := src.MarshalBinary()
+		requireZero(t, err)
+
+		dst := new(Value[hash])
+		dst.Store(hash{0xff}) // should be overwritten or reset
+		requireZero(t, dst.UnmarshalBinary(b))
+		return dst
+	}
+
+	var a Value[hash]
+	b, err := a.MarshalBinary()
+	requireZero(t, err)
+	requireEqual(t, true, bytes.Equal([]byte{binaryUnset}, b))
+	requireEqual(t, false, roundTrip(t, &a).IsSet())
+
+	a.Store(hash{})
+	dst := roundTrip(t, &a)
+	requireEqual(t, true, dst.IsSet())
+	requireEqual(t, hash{}, dst.Load())
+
+	a.Store(hash{1, 2, 3, 31: 4})
+	b, err = a.MarshalBinary()
+	requireZero(t, err)
+	requireEqual(t, 33, len(b))
+	requireEqual(t, hash{1, 2, 3, 31: 4}, roundTrip(t, &a).Load())
+
+	t.Run("numeric struct", func(t *testing.T) {
+		type sample struct {
+			N    int64
+			Ok   bool
+			F    float64
+			C    complex64
+			Hist [4]uint16
+		}
+
+		src := New(sample{N: -3, Ok: true, F: 1.5, C: 2i, Hist: [4]uint16{1, 2, 3, 4}})
+		b, err := src.MarshalBinary()
+		requireZero(t, err)
+
+		var dst Value[sample]
+		requireZero(t, dst.UnmarshalBinary(b))
+		requireEqual(t, src.Load(), dst.Load())
+	})
+
+	t.Run("pointers", func(t *testing.T) {
+		check := func(t *testing.T, marshal func() ([]byte, error), unmarshal func([]byte) error) {
+			t.Helper()
+			_, err := marshal()
+			requireNotZero(t, err)
+			requireNotZero(t, unmarshal([]byte{binaryUnset}))
+		}
+
+		var p Value[*int]
+		check(t, p.MarshalBinary, p.UnmarshalBinary)
+		var s Value[string]
+		check(t, s.MarshalBinary, s.UnmarshalBinary)
+		var i Value[any]
+		check(t, i.MarshalBinary, i.UnmarshalBinary)
+		var st Value[struct {
+			N    int
+			Next *int
+		}]
+		check(t, st.MarshalBinary, st.UnmarshalBinary)
+		var arr Value[[2]chan int]
+		check(t, arr.MarshalBinary, arr.UnmarshalBinary)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var v Value[int32]
+		v.Store(1)
+		requireNotZero(t, v.UnmarshalBinary(nil))
+		requireNotZero(t, v.UnmarshalBinary([]byte{binaryUnset, 0}))
+		requireNotZero(t, v.UnmarshalBinary([]byte{7}))
+		requireNotZero(t, v.UnmarshalBinary([]byte{binarySet, 0xff}))
+		requireNotZero(t, v.UnmarshalBinary([]byte{binarySet, 1, 2, 3, 4, 5}))
+		requireEqual(t, int32(1), v.Load())
+	})
+}
+
+func BenchmarkValue_Binary(b *testing.B) {
+	av := New([32]byte{1})
+
+	b.Run("MarshalBinary", func(b *testing.B) {
+		for range b.N {
+			_, _ = av.MarshalBinary()
+		}
+	})
+
+	b.Run("GobEncode", func(b *testing.B) {
+		for range b.N {
+			_, _ = av.GobEncode()
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package atomicval
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// EnumValue is an atomic store restricted to a fixed set of named values of
+// type T, such as the constants of an enum. Values may be stored and loaded
+// directly or by name, and EnumValue implements [encoding.TextMarshaler] and
+// [encoding.TextUnmarshaler] in terms of those names.
+//
+// Like [Value], an unset EnumValue loads as the zero value of T, even if that
+// value has no name. The zero EnumValue has an empty name table, so every
+// Store is rejected; use [NewEnumValue] to construct a useful one.
+//
+// Must not be copied after first use.
+type EnumValue[T comparable] struct {
+	v Value[T]
+
+	byName map[string]T
+	byVal  map[T]string
+	names  []string // sorted, for error messages
+}
+
+// NewEnumValue returns an unset [EnumValue] allowing the values in names, keyed
+// by their textual names. If several names map to the same value, the
+// lexically smallest one is used when formatting. names is copied, so later
+// changes to it have no effect.
+func NewEnumValue[T comparable](names map[string]T) *EnumValue[T] {
+	e := &EnumValue[T]{
+		byName: make(map[string]T, len(names)),
+		byVal:  make(map[T]string, len(names)),
+		names:  make([]string, 0, len(names)),
+	}
+
+	for name, val := range names {
+		e.byName[name] = val
+		e.names = append(e.names, name)
+	}
+
+	slices.Sort(e.names)
+	for _, name := range slices.Backward(e.names) {
+		e.byVal[e.byName[name]] = name
+	}
+
+	return e
+}
+
+// Load returns the value set by the most recent successful Store. Returns the
+// zero value if no value has been set.
+func (e *EnumValue[T]) Load() T { return e.v.Load() }
+
+// Store sets the value to val if it is one of the allowed values, otherwise it
+// returns an error and leaves the current value unchanged.
+func (e *EnumValue[T]) Store(val T) error {
+	if _, ok := e.byVal[val]; !ok {
+		return fmt.Errorf("atomicval: %v is not an allowed %T value", val, val)
+	}
+
+	e.v.Store(val)
+	return nil
+}
+
+// LoadString returns the name of the current value, or "" if it has no name
+// (i.e. the EnumValue is unset and the zero value of T is not allowed).
+func (e *EnumValue[T]) LoadString() string { return e.byVal[e.v.Load()] }
+
+// StoreString sets the value to the one named s, or returns an error if there
+// is no such name, leaving the current value unchanged.
+func (e *EnumValue[T]) StoreString(s string) error {
+	val, ok := e.byName[s]
+	if !ok {
+		return fmt.Errorf("atomicval: unknown %T name %q (expected one of: %s)",
+			val, s, strings.Join(e.names, ", "))
+	}
+
+	e.v.Store(val)
+	return nil
+}
+
+// MarshalText implements [encoding.TextMarshaler] by returning the name of the
+// current value. An error is returned if the value has no name.
+func (e *EnumValue[T]) MarshalText() ([]byte, error) {
+	val := e.v.Load()
+	name, ok := e.byVal[val]
+	if !ok {
+		return nil, fmt.Errorf("atomicval: %T value %v has no name", val, val)
+	}
+
+	return []byte(name), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler] via [EnumValue.StoreString].
+func (e *EnumValue[T]) UnmarshalText(text []byte) error {
+	return e.StoreString(string(text))
+}
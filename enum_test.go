@@ -0,0 +1,104 @@
+package atomicval
+
+import (
+	"encoding"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type level int
+
+const (
+	levelDebug level = iota + 1
+	levelInfo
+	levelError
+)
+
+var levelNames = map[string]level{
+	"debug":   levelDebug,
+	"info":    levelInfo,
+	"error":   levelError,
+	"warning": levelError + 1,
+	"warn":    levelError + 1, // alias, "warn" is used for formatting
+}
+
+var (
+	_ encoding.TextMarshaler   = (*EnumValue[level])(nil)
+	_ encoding.TextUnmarshaler = (*EnumValue[level])(nil)
+)
+
+func TestEnumValue(t *testing.T) {
+	e := NewEnumValue(levelNames)
+
+	// unset: zero value, which has no name
+	requireZero(t, e.Load())
+	requireEqual(t, "", e.LoadString())
+	if _, err := e.MarshalText(); err == nil {
+		t.Fatal("expected error marshaling unnamed value")
+	}
+
+	for name, val := range levelNames {
+		requireEqual(t, nil, e.StoreString(name))
+		requireEqual(t, val, e.Load())
+
+		requireEqual(t, nil, e.Store(val))
+		got := e.LoadString()
+		requireEqual(t, val, levelNames[got])
+	}
+
+	requireEqual(t, nil, e.Store(levelError+1))
+	requireEqual(t, "warn", e.LoadString())
+
+	t.Run("rejects unknown", func(t *testing.T) {
+		e := NewEnumValue(levelNames)
+		requireEqual(t, nil, e.Store(levelInfo))
+
+		err := e.StoreString("verbose")
+		requireNotZero(t, err)
+		for _, s := range []string{`"verbose"`, "debug, error, info, warn, warning"} {
+			if !strings.Contains(err.Error(), s) {
+				t.Fatalf("error %q should contain %q", err, s)
+			}
+		}
+
+		requireNotZero(t, e.Store(level(100)))
+		requireNotZero(t, e.Store(0))
+		requireEqual(t, levelInfo, e.Load())
+	})
+
+	t.Run("zero EnumValue", func(t *testing.T) {
+		var e EnumValue[level]
+		requireZero(t, e.Load())
+		requireNotZero(t, e.Store(levelInfo))
+		requireNotZero(t, e.StoreString("info"))
+		requireZero(t, e.Load())
+	})
+
+	t.Run("names table is copied", func(t *testing.T) {
+		names := map[string]level{"info": levelInfo}
+		e := NewEnumValue(names)
+		names["debug"] = levelDebug
+		requireNotZero(t, e.StoreString("debug"))
+	})
+
+	t.Run("text round-trip", func(t *testing.T) {
+		type config struct {
+			Level *EnumValue[level]
+		}
+
+		in := config{NewEnumValue(levelNames)}
+		requireEqual(t, nil, in.Level.Store(levelError))
+
+		b, err := json.Marshal(in)
+		requireEqual(t, nil, err)
+		requireEqual(t, `{"Level":"error"}`, string(b))
+
+		out := config{NewEnumValue(levelNames)}
+		requireEqual(t, nil, json.Unmarshal(b, &out))
+		requireEqual(t, levelError, out.Level.Load())
+
+		requireNotZero(t, json.Unmarshal([]byte(`{"Level":"loud"}`), &out))
+		requireEqual(t, levelError, out.Level.Load())
+	})
+}
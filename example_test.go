@@ -0,0 +1,49 @@
+package atomicval_test
+
+import (
+	"fmt"
+
+	"github.com/rhallora-heidelberg/atomicval"
+)
+
+func ExampleNew() {
+	type server struct {
+		name string
+		mode *atomicval.Value[string]
+		hits *atomicval.Value[int]
+	}
+
+	s := server{
+		name: "a",
+		mode: atomicval.New("serving"),
+		hits: atomicval.NewUnset[int](),
+	}
+
+	fmt.Println(s.mode.Load(), s.mode.IsSet())
+	fmt.Println(s.hits.Load(), s.hits.IsSet())
+
+	// Output:
+	// serving true
+	// 0 false
+}
+
+// lookup returns a Value holding m[k], or an unset Value if k is not in m.
+func lookup[K, V comparable](m map[K]V, k K) *atomicval.Value[V] {
+	if val, ok := m[k]; ok {
+		return atomicval.ValueOf(val) // V inferred from val
+	}
+
+	return atomicval.ZeroValue[V]()
+}
+
+func ExampleValueOf() {
+	ports := map[string]int{"http": 80}
+
+	http, gopher := lookup(ports, "http"), lookup(ports, "gopher")
+	fmt.Println(http.Load(), http.IsSet())
+	fmt.Println(gopher.Load(), gopher.IsSet())
+
+	// Output:
+	// 80 true
+	// 0 false
+}
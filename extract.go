@@ -0,0 +1,89 @@
+package atomicval
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// reflectLoader is implemented by *[Value] for any T, allowing reflection-based
+// helpers to handle Values without knowing T.
+type reflectLoader interface {
+	reflectLoad() reflect.Value
+}
+
+// reflectLoad returns the result of Load as a (non-addressable) reflect.Value
+// of type T. Interface-typed T yields a valid reflect.Value even for nil.
+func (v *Value[T]) reflectLoad() reflect.Value {
+	val := v.Load()
+	return reflect.ValueOf(&val).Elem()
+}
+
+// Extract copies a snapshot of every exported [Value] field in the struct
+// pointed to by src into the same-named field of the struct pointed to by dst.
+// Fields holding unset Values receive the zero value. Fields of src which are
+// not Values, and fields of dst with no Value counterpart, are left alone.
+//
+// An error is returned if either argument is not a non-nil pointer to a
+// struct, if dst has no field matching a Value field of src, or if the types
+// are not assignable. Fields copied before the error is encountered remain
+// written.
+//
+// Each field is loaded independently, so the result is not a consistent
+// snapshot across fields if src is being modified concurrently.
+func Extract(dst, src any) error {
+	dv, err := structElem(dst)
+	if err != nil {
+		return fmt.Errorf("atomicval: Extract dst: %w", err)
+	}
+
+	sv, err := structElem(src)
+	if err != nil {
+		return fmt.Errorf("atomicval: Extract src: %w", err)
+	}
+
+	st := sv.Type()
+	for i := range st.NumField() {
+		sf := st.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		loader, ok := sv.Field(i).Addr().Interface().(reflectLoader)
+		if !ok {
+			continue
+		}
+
+		df := dv.FieldByName(sf.Name)
+		if !df.IsValid() {
+			return fmt.Errorf("atomicval: Extract: dst %s has no field %s", dv.Type(), sf.Name)
+		}
+
+		val := loader.reflectLoad()
+		if !val.Type().AssignableTo(df.Type()) {
+			return fmt.Errorf("atomicval: Extract: field %s: cannot assign %s to %s", sf.Name, val.Type(), df.Type())
+		}
+
+		if !df.CanSet() {
+			return fmt.Errorf("atomicval: Extract: dst field %s is not settable", sf.Name)
+		}
+
+		df.Set(val)
+	}
+
+	return nil
+}
+
+// structElem returns the struct pointed to by ptr.
+func structElem(ptr any) (reflect.Value, error) {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return reflect.Value{}, fmt.Errorf("expected non-nil pointer to struct, got %T", ptr)
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("expected non-nil pointer to struct, got %T", ptr)
+	}
+
+	return rv, nil
+}
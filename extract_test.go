@@ -0,0 +1,95 @@
+package atomicval
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	type liveConfig struct {
+		Name    Value[string]
+		Limit   Value[int]
+		Timeout Value[time.Duration]
+		Out     Value[io.Writer]
+		Unset   Value[ex]
+
+		Comment string // not a Value, ignored
+		private Value[int]
+	}
+
+	type plainConfig struct {
+		Name    string
+		Limit   int
+		Timeout time.Duration
+		Out     io.Writer
+		Unset   ex
+
+		Comment string
+		Extra   bool // no counterpart, left alone
+	}
+
+	var src liveConfig
+	src.Name.Store("svc")
+	src.Limit.Store(10)
+	src.Timeout.Store(time.Second)
+	src.Out.Store(io.Discard)
+	src.Comment = "ignored"
+	src.private.Store(1)
+
+	dst := plainConfig{Unset: ex{a: 1}, Extra: true}
+	if err := Extract(&dst, &src); err != nil {
+		t.Fatal(err)
+	}
+
+	requireEqual(t, plainConfig{
+		Name:    "svc",
+		Limit:   10,
+		Timeout: time.Second,
+		Out:     io.Discard,
+		Extra:   true,
+	}, dst)
+
+	t.Run("assignable", func(t *testing.T) {
+		var src struct{ W Value[*strings.Builder] }
+		sb := new(strings.Builder)
+		src.W.Store(sb)
+
+		var dst struct{ W io.Writer }
+		if err := Extract(&dst, &src); err != nil {
+			t.Fatal(err)
+		}
+		requireEqual[io.Writer](t, sb, dst.W)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		var src struct{ Limit Value[int] }
+		src.Limit.Store(1)
+
+		dst := struct{ Limit string }{"unchanged"}
+		err := Extract(&dst, &src)
+		requireNotZero[error](t, err)
+		requireEqual(t, "unchanged", dst.Limit)
+		if !strings.Contains(err.Error(), "Limit") {
+			t.Fatalf("error should name the field: %v", err)
+		}
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		var src struct{ Limit Value[int] }
+		var dst struct{ Other int }
+		requireNotZero[error](t, Extract(&dst, &src))
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		var src struct{ Limit Value[int] }
+		var dst struct{ Limit int }
+		var nilPtr *struct{ Limit int }
+
+		requireNotZero[error](t, Extract(dst, &src))
+		requireNotZero[error](t, Extract(nilPtr, &src))
+		requireNotZero[error](t, Extract(new(int), &src))
+		requireNotZero[error](t, Extract(&dst, nil))
+	})
+}
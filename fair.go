@@ -0,0 +1,75 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// FairValue is a [Value] whose mutating operations are served in arrival
+// order. Under heavy contention, CompareAndSwap-retry loops on a [Value] let
+// some goroutines win repeatedly while others starve; FairValue instead hands
+// out tickets so that each mutator waits behind at most one operation from
+// each other contending goroutine.
+//
+// The tradeoff is throughput: mutators are fully serialized, and a waiting
+// goroutine yields until its ticket comes up, so a holder which is descheduled
+// stalls everyone queued behind it. Prefer [Value] unless bounded waiting is
+// actually needed. Loads do not take a ticket and are as cheap as
+// [Value.Load].
+//
+// The zero FairValue is ready for use. Must not be copied after first use.
+type FairValue[T comparable] struct {
+	v Value[T]
+
+	next    atomic.Uint64 // next ticket to hand out
+	serving atomic.Uint64 // ticket currently allowed to mutate
+}
+
+func (f *FairValue[T]) acquire() {
+	ticket := f.next.Add(1) - 1
+	for f.serving.Load() != ticket {
+		runtime.Gosched()
+	}
+}
+
+func (f *FairValue[T]) release() { f.serving.Add(1) }
+
+// Load returns the value set by the most recent mutation. Returns the zero
+// value if no value has been set.
+func (f *FairValue[T]) Load() T { return f.v.Load() }
+
+// Store sets the value to val, once all previously arrived mutations are done.
+func (f *FairValue[T]) Store(val T) {
+	f.acquire()
+	defer f.release()
+
+	f.v.Store(val)
+}
+
+// Swap stores new and returns the previous value, once all previously arrived
+// mutations are done. Returns the zero value if no value had been set.
+func (f *FairValue[T]) Swap(new T) (old T) {
+	f.acquire()
+	defer f.release()
+
+	return f.v.Swap(new)
+}
+
+// CompareAndSwap executes the compare-and-swap operation once all previously
+// arrived mutations are done, with the semantics of [Value.CompareAndSwap].
+func (f *FairValue[T]) CompareAndSwap(old, new T) (swapped bool) {
+	f.acquire()
+	defer f.release()
+
+	return f.v.CompareAndSwap(old, new)
+}
+
+// Update replaces the current value (or the zero value, if unset) with the
+// result of fn, once all previously arrived mutations are done. Since
+// mutations are serialized, fn is called exactly once and never retried.
+func (f *FairValue[T]) Update(fn func(old T) (new T)) {
+	f.acquire()
+	defer f.release()
+
+	f.v.Store(fn(f.v.Load()))
+}
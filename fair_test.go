@@ -0,0 +1,81 @@
+package atomicval
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestFairValue(t *testing.T) {
+	var a FairValue[int]
+	requireZero(t, a.Load())
+	a.Store(1)
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, 1, a.Swap(2))
+	requireEqual(t, true, a.CompareAndSwap(2, 3))
+	requireEqual(t, false, a.CompareAndSwap(2, 4))
+	a.Update(func(old int) int { return old * 10 })
+	requireEqual(t, 30, a.Load())
+
+	var b FairValue[io.Writer]
+	requireEqual(t, true, b.CompareAndSwap(nil, io.Discard))
+	requireEqual[io.Writer](t, io.Discard, b.Load())
+
+	t.Run("panicking Update releases its turn", func(t *testing.T) {
+		var v FairValue[int]
+		func() {
+			defer func() { _ = recover() }()
+			v.Update(func(int) int { panic("boom") })
+		}()
+
+		v.Store(1)
+		requireEqual(t, 1, v.Load())
+	})
+
+	t.Run("fairness", func(t *testing.T) {
+		// each goroutine repeatedly increments the value, recording the
+		// ticket each of its updates held and the position at which it ran.
+		// Updates are served in ticket order, so the update holding ticket n
+		// is the n-th to run: at most goroutines-1 updates, all holding
+		// earlier tickets, can run between drawing a ticket and being served.
+		goroutines := 8 * runtime.GOMAXPROCS(0)
+		iters := 200
+		if testing.Short() {
+			iters = 50
+		}
+
+		var v FairValue[int]
+		var wg sync.WaitGroup
+		type served struct{ ticket, position int }
+		updates := make([][]served, goroutines)
+		for g := range goroutines {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					v.Update(func(old int) int {
+						// the ticket being served is the one this update holds
+						ticket := int(v.serving.Load())
+						updates[g] = append(updates[g], served{ticket, old})
+						return old + 1
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, goroutines*iters, v.Load())
+		for g, us := range updates {
+			requireEqual(t, iters, len(us))
+			for i, u := range us {
+				if u.position != u.ticket {
+					t.Fatalf("goroutine %d: update with ticket %d ran at position %d", g, u.ticket, u.position)
+				}
+				if i > 0 && u.ticket <= us[i-1].ticket {
+					t.Fatalf("goroutine %d: ticket %d drawn after %d", g, u.ticket, us[i-1].ticket)
+				}
+			}
+		}
+	})
+}
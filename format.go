@@ -0,0 +1,52 @@
+package atomicval
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// unsetText is how an unset Value is formatted.
+const unsetText = "<unset>"
+
+// String implements [fmt.Stringer] and [expvar.Var], returning the JSON
+// encoding of the current value (see [Value.MarshalJSON]), so that a *Value can
+// be published with [expvar.Publish]. Returns "null" if no value has been set,
+// or if the value can't be encoded as JSON. Formatting a *Value with fmt uses
+// [Value.Format] instead.
+func (v *Value[T]) String() string {
+	b, err := v.MarshalJSON()
+	if err != nil {
+		return "null"
+	}
+
+	return string(b)
+}
+
+// Format implements [fmt.Formatter], formatting the current value as if it
+// were passed to fmt directly, with the same verb and flags (so e.g. %+v and
+// %#v include struct field names). An unset Value is formatted as "<unset>",
+// regardless of the verb.
+//
+// Since the method has a pointer receiver, fmt only uses it when given a
+// *Value, or a pointer to a struct embedding a Value.
+func (v *Value[T]) Format(f fmt.State, verb rune) {
+	val, ok := v.LoadOption().Get()
+	if !ok {
+		_, _ = fmt.Fprint(f, unsetText)
+		return
+	}
+
+	_, _ = fmt.Fprintf(f, fmt.FormatString(f, verb), val)
+}
+
+// LogValue implements [slog.LogValuer], so that a *Value logs as its current
+// value (which may itself be a [slog.LogValuer]), or as the string "<unset>"
+// if no value has been set.
+func (v *Value[T]) LogValue() slog.Value {
+	val, ok := v.LoadOption().Get()
+	if !ok {
+		return slog.StringValue(unsetText)
+	}
+
+	return slog.AnyValue(val)
+}
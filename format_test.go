@@ -0,0 +1,145 @@
+package atomicval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+func TestValue_String(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, "null", a.String())
+
+	a.Store(0)
+	requireEqual(t, "0", a.String())
+
+	var p Value[*int]
+	p.Store(nil)
+	requireEqual(t, "null", p.String())
+
+	var s Value[string]
+	s.Store(`say "hi"`)
+	requireEqual(t, `"say \"hi\""`, s.String())
+
+	var c Value[chan int]
+	c.Store(make(chan int))
+	requireEqual(t, "null", c.String()) // unsupported by encoding/json
+
+	t.Run("expvar", func(t *testing.T) {
+		type config struct {
+			Name  string
+			Limit int
+		}
+
+		var cfg Value[config]
+		var m expvar.Map
+		m.Set("config", &cfg)
+		requireEqual(t, `{"config": null}`, m.String())
+
+		cfg.Store(config{Name: "a", Limit: 1})
+		cfg.Store(config{Name: "b", Limit: 2})
+		requireEqual(t, `{"config": {"Name":"b","Limit":2}}`, m.String())
+
+		var got map[string]config
+		requireZero(t, json.Unmarshal([]byte(m.String()), &got))
+		requireEqual(t, config{Name: "b", Limit: 2}, got["config"])
+	})
+}
+
+func TestValue_Format(t *testing.T) {
+	type point struct{ X, Y int }
+
+	var a Value[point]
+	requireEqual(t, "<unset>", fmt.Sprintf("%v", &a))
+	requireEqual(t, "<unset>", fmt.Sprintf("%#v", &a))
+
+	a.Store(point{})
+	requireEqual(t, "{0 0}", fmt.Sprintf("%v", &a))
+
+	a.Store(point{X: 1, Y: 2})
+	requireEqual(t, "{1 2}", fmt.Sprintf("%v", &a))
+	requireEqual(t, "{X:1 Y:2}", fmt.Sprintf("%+v", &a))
+	requireEqual(t, "atomicval.point{X:1, Y:2}", fmt.Sprintf("%#v", &a))
+
+	var n Value[float64]
+	n.Store(3.14159)
+	requireEqual(t, "  3.14", fmt.Sprintf("%6.2f", &n))
+	requireEqual(t, "3.14  ", fmt.Sprintf("%-6.2f", &n))
+
+	var p Value[*point]
+	p.Store(&point{X: 1})
+	requireEqual(t, "&{X:1 Y:0}", fmt.Sprintf("%+v", &p))
+
+	p.Store(nil)
+	requireEqual(t, "<nil>", fmt.Sprintf("%v", &p))
+	requireEqual(t, "(*atomicval.point)(nil)", fmt.Sprintf("%#v", &p))
+
+	t.Run("nested", func(t *testing.T) {
+		type state struct {
+			Name  string
+			Count *Value[int]
+		}
+
+		var c Value[int]
+		c.Store(5)
+		requireEqual(t, "{Name:s Count:5}", fmt.Sprintf("%+v", state{Name: "s", Count: &c}))
+	})
+}
+
+// attrRecorder is a [slog.Handler] which records the resolved attributes of
+// each record.
+type attrRecorder struct{ attrs map[string]slog.Value }
+
+func (r *attrRecorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (r *attrRecorder) Handle(_ context.Context, rec slog.Record) error {
+	rec.Attrs(func(a slog.Attr) bool {
+		r.attrs[a.Key] = a.Value.Resolve()
+		return true
+	})
+	return nil
+}
+
+func (r *attrRecorder) WithAttrs([]slog.Attr) slog.Handler { return r }
+func (r *attrRecorder) WithGroup(string) slog.Handler      { return r }
+
+type secret string
+
+func (secret) LogValue() slog.Value { return slog.StringValue("REDACTED") }
+
+func TestValue_LogValue(t *testing.T) {
+	rec := &attrRecorder{attrs: make(map[string]slog.Value)}
+	logger := slog.New(rec)
+
+	var n Value[int]
+	logger.Info("msg", "n", &n)
+	requireEqual(t, slog.KindString, rec.attrs["n"].Kind())
+	requireEqual(t, "<unset>", rec.attrs["n"].String())
+
+	n.Store(3)
+	logger.Info("msg", "n", &n)
+	requireEqual(t, slog.KindInt64, rec.attrs["n"].Kind())
+	requireEqual(t, int64(3), rec.attrs["n"].Int64())
+
+	// a LogValuer T is resolved in turn
+	var s Value[secret]
+	s.Store("hunter2")
+	logger.Info("msg", "password", &s)
+	requireEqual(t, "REDACTED", rec.attrs["password"].String())
+
+	type config struct {
+		Name  string
+		Limit int
+	}
+
+	var cfg Value[config]
+	cfg.Store(config{Name: "a", Limit: 3})
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("msg", slog.Any("cfg", &cfg))
+	requireEqual(t, true, bytes.Contains(buf.Bytes(), []byte(`"cfg":{"Name":"a","Limit":3}`)))
+}
@@ -0,0 +1,67 @@
+package atomicval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Handler returns an [http.Handler] exposing the [Value] as a JSON resource:
+//   - GET (and HEAD) respond with the JSON encoding of the Value, as by
+//     [Value.MarshalJSON]: null if it is unset
+//   - PUT decodes the request body as a single JSON value of type T and
+//     stores it, responding with 204 No Content; malformed bodies (including
+//     unknown object fields or trailing data) are rejected with 400 Bad Request
+//     and leave the value unchanged
+//
+// Other methods are rejected with 405 Method Not Allowed. The handler performs
+// no authentication; mount it accordingly.
+func (v *Value[T]) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			b, err := json.Marshal(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(append(b, '\n'))
+			}
+
+		case http.MethodPut:
+			val, err := decodeJSONBody[T](r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			v.Store(val)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// decodeJSONBody strictly decodes exactly one JSON value of type T from body.
+func decodeJSONBody[T any](body io.Reader) (val T, err error) {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&val); err != nil {
+		return val, fmt.Errorf("invalid body: %w", err)
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return val, errors.New("invalid body: unexpected data after JSON value")
+	}
+
+	return val, nil
+}
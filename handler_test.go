@@ -0,0 +1,92 @@
+package atomicval
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValue_Handler(t *testing.T) {
+	type config struct {
+		Name  string `json:"name"`
+		Limit int    `json:"limit"`
+	}
+
+	var v Value[config]
+	srv := httptest.NewServer(v.Handler())
+	defer srv.Close()
+
+	do := func(t *testing.T, method, body string) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequest(method, srv.URL, strings.NewReader(body))
+		requireEqual(t, nil, err)
+		resp, err := srv.Client().Do(req)
+		requireEqual(t, nil, err)
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		requireEqual(t, nil, err)
+		return resp, string(b)
+	}
+
+	t.Run("GET unset", func(t *testing.T) {
+		resp, body := do(t, http.MethodGet, "")
+		requireEqual(t, http.StatusOK, resp.StatusCode)
+		requireEqual(t, "application/json", resp.Header.Get("Content-Type"))
+		requireEqual(t, "null\n", body)
+	})
+
+	t.Run("GET zero value", func(t *testing.T) {
+		// set, if to the zero value, is distinct from unset
+		v.Store(config{})
+		_, body := do(t, http.MethodGet, "")
+		requireEqual(t, `{"name":"","limit":0}`+"\n", body)
+	})
+
+	t.Run("PUT then GET", func(t *testing.T) {
+		resp, _ := do(t, http.MethodPut, `{"name":"svc","limit":3}`)
+		requireEqual(t, http.StatusNoContent, resp.StatusCode)
+		requireEqual(t, config{"svc", 3}, v.Load())
+
+		resp, body := do(t, http.MethodGet, "")
+		requireEqual(t, http.StatusOK, resp.StatusCode)
+		requireEqual(t, `{"name":"svc","limit":3}`+"\n", body)
+	})
+
+	t.Run("GET reflects Store", func(t *testing.T) {
+		v.Store(config{"other", 4})
+		_, body := do(t, http.MethodGet, "")
+		requireEqual(t, `{"name":"other","limit":4}`+"\n", body)
+	})
+
+	t.Run("HEAD", func(t *testing.T) {
+		resp, body := do(t, http.MethodHead, "")
+		requireEqual(t, http.StatusOK, resp.StatusCode)
+		requireEqual(t, "", body)
+	})
+
+	t.Run("malformed bodies", func(t *testing.T) {
+		v.Store(config{"keep", 1})
+		for _, body := range []string{
+			``,
+			`{"name":`,
+			`{"name":1}`,
+			`{"nmae":"typo"}`,
+			`{"name":"a"} {"name":"b"}`,
+			`[]`,
+		} {
+			resp, _ := do(t, http.MethodPut, body)
+			requireEqual(t, http.StatusBadRequest, resp.StatusCode)
+		}
+		requireEqual(t, config{"keep", 1}, v.Load())
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp, _ := do(t, http.MethodPost, `{}`)
+		requireEqual(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		requireEqual(t, "GET, HEAD, PUT", resp.Header.Get("Allow"))
+	})
+}
@@ -0,0 +1,69 @@
+package atomicval
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Lazy holds a value which is computed on first access, at most once. Unlike
+// [sync.Once], the computed value is held by the Lazy itself, and can be
+// inspected without forcing the computation via [Lazy.Peek].
+//
+// If the initializer panics, the panic propagates to the caller of Get and
+// nothing is cached: the next Get runs the initializer again.
+//
+// A Lazy must be created with [NewLazy]. Must not be copied after first use.
+type Lazy[T any] struct {
+	_ noCopy
+
+	val atomic.Pointer[T] // nil until computed
+
+	mu sync.Mutex // serializes initialization
+	fn func() T   // released once computed
+}
+
+// NewLazy returns a [Lazy] whose value is computed by fn on first access.
+func NewLazy[T any](fn func() T) *Lazy[T] {
+	return &Lazy[T]{fn: fn}
+}
+
+// Get returns the value, computing it first if this is the first call. Of
+// several goroutines calling Get concurrently before the value is computed,
+// one runs the initializer while the others wait for its result. Once the
+// value is computed, Get costs a single atomic load.
+func (l *Lazy[T]) Get() T {
+	if p := l.val.Load(); p != nil {
+		return *p
+	}
+
+	return l.init()
+}
+
+func (l *Lazy[T]) init() T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// lost the race to a concurrent Get
+	if p := l.val.Load(); p != nil {
+		return *p
+	}
+
+	var val T
+	if l.fn != nil {
+		val = l.fn() // if this panics, the deferred Unlock leaves l as it was
+	}
+
+	l.val.Store(&val)
+	l.fn = nil
+	return val
+}
+
+// Peek returns the value and true if it has already been computed, or the
+// zero value and false otherwise, without running the initializer.
+func (l *Lazy[T]) Peek() (val T, ok bool) {
+	if p := l.val.Load(); p != nil {
+		return *p, true
+	}
+
+	return val, false
+}
@@ -0,0 +1,91 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	l := NewLazy(func() int {
+		calls++
+		return 42
+	})
+
+	val, ok := l.Peek()
+	requireZero(t, val)
+	requireEqual(t, false, ok)
+	requireEqual(t, 0, calls)
+
+	requireEqual(t, 42, l.Get())
+	requireEqual(t, 42, l.Get())
+	requireEqual(t, 1, calls)
+
+	val, ok = l.Peek()
+	requireEqual(t, 42, val)
+	requireEqual(t, true, ok)
+
+	t.Run("zero value is cached", func(t *testing.T) {
+		calls := 0
+		l := NewLazy(func() *int {
+			calls++
+			return nil
+		})
+		requireEqual(t, (*int)(nil), l.Get())
+		requireEqual(t, (*int)(nil), l.Get())
+		requireEqual(t, 1, calls)
+
+		_, ok := l.Peek()
+		requireEqual(t, true, ok)
+	})
+
+	t.Run("panic is not cached", func(t *testing.T) {
+		calls := 0
+		l := NewLazy(func() string {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			return "ok"
+		})
+
+		func() {
+			defer func() { requireEqual[any](t, "boom", recover()) }()
+			l.Get()
+		}()
+
+		_, ok := l.Peek()
+		requireEqual(t, false, ok)
+		requireEqual(t, "ok", l.Get())
+		requireEqual(t, 2, calls)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var calls atomic.Int32
+		l := NewLazy(func() []int {
+			calls.Add(1)
+			runtime.Gosched()
+			return []int{1, 2, 3}
+		})
+
+		n := 8 * runtime.GOMAXPROCS(0)
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if got := l.Get(); len(got) != 3 {
+					t.Errorf("unexpected value: %v", got)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		requireEqual(t, int32(1), calls.Load())
+	})
+}
@@ -0,0 +1,247 @@
+package atomicval
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// hooks is an immutable snapshot of the observers of a Value. Registering or
+// removing an observer publishes a new snapshot, so that notifying them takes
+// no locks beyond those of the observers themselves.
+type hooks[T comparable] struct {
+	owner    *Value[T] // the Value observed, see [Value.observers]
+	subs     []*subscription[T]
+	onChange func(old, new T)
+}
+
+// observers returns v's hooks, or nil if it has none. A Value which was
+// (wrongly) copied carries over the hooks of the original, but they belong to
+// the original alone, and are not returned for the copy.
+func (v *Value[T]) observers() *hooks[T] {
+	if h := v.hooks.Load(); h != nil && h.owner == v {
+		return h
+	}
+
+	return nil
+}
+
+// changed notifies v's observers, if any, after a successful mutation which
+// replaced the box old with new. Without observers this costs a single atomic
+// load.
+func (v *Value[T]) changed(old, new unsafe.Pointer) {
+	if h := v.observers(); h != nil {
+		h.notify(v, old, new)
+	}
+}
+
+func (h *hooks[T]) notify(v *Value[T], old, new unsafe.Pointer) {
+	if h.onChange != nil {
+		h.onChange(boxed[T](old), boxed[T](new))
+	}
+
+	for _, s := range h.subs {
+		s.deliver(v)
+	}
+}
+
+// boxed returns the value in box, or the zero value if box is nil.
+func boxed[T comparable](box unsafe.Pointer) (val T) {
+	if box == nil {
+		return val
+	}
+
+	return *(*T)(box)
+}
+
+// editHooks atomically replaces v's hooks with the result of fn, which is
+// passed a copy of the current ones (and may be called more than once). An
+// empty result removes the hooks altogether.
+func (v *Value[T]) editHooks(fn func(h *hooks[T])) {
+	for {
+		old := v.hooks.Load()
+
+		h := &hooks[T]{owner: v}
+		if old != nil && old.owner == v {
+			h.subs = slices.Clone(old.subs)
+			h.onChange = old.onChange
+		}
+
+		fn(h)
+
+		if len(h.subs) == 0 && h.onChange == nil {
+			h = nil
+		}
+
+		if v.hooks.CompareAndSwap(old, h) {
+			return
+		}
+	}
+}
+
+// subscription is a channel registered by [Value.Subscribe].
+type subscription[T comparable] struct {
+	mu     sync.Mutex // serializes deliveries, and closing
+	ch     chan T     // holds at most the latest undelivered value
+	closed bool
+}
+
+// deliver replaces any value still pending in s with the current value of v.
+// Since deliveries are serialized, and each one loads v after the mutation
+// that triggered it, the last delivery always carries the latest value.
+func (s *subscription[T]) deliver(v *Value[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case <-s.ch:
+	default:
+	}
+
+	// can't block: the buffer was just emptied, and only deliver sends
+	s.ch <- boxed[T](atomic.LoadPointer(&v.v))
+}
+
+// current discards any value pending in s and returns the current value of v.
+// Every value delivered to s afterwards is at least as recent as the one
+// returned, since deliveries are serialized with this.
+func (s *subscription[T]) current(v *Value[T]) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.ch:
+	default:
+	}
+
+	return boxed[T](atomic.LoadPointer(&v.v))
+}
+
+// Subscribe returns a channel which receives the new value after each
+// successful mutation of the [Value] (Store, Swap, a successful CompareAndSwap,
+// Reset and so on; a reset is delivered as the zero value), and a function to
+// cancel the subscription.
+//
+// Notifications never block the mutating goroutine. The channel holds at most
+// one value: if the subscriber falls behind, undelivered values are replaced,
+// so that a receiver always sees the latest value eventually, but not
+// necessarily every intermediate one. Only mutations which happen after
+// Subscribe returns are guaranteed to be delivered.
+//
+// Calling the cancel function closes the channel, after which no more values
+// are delivered. It may be called any number of times, from any goroutine.
+//
+// Observing a Value costs its mutators an additional load of the current value
+// for each subscription.
+func (v *Value[T]) Subscribe() (<-chan T, func()) {
+	s, cancel := v.subscribe()
+	return s.ch, cancel
+}
+
+func (v *Value[T]) subscribe() (*subscription[T], func()) {
+	s := &subscription[T]{ch: make(chan T, 1)}
+	v.editHooks(func(h *hooks[T]) { h.subs = append(h.subs, s) })
+
+	cancel := sync.OnceFunc(func() {
+		v.editHooks(func(h *hooks[T]) {
+			h.subs = slices.DeleteFunc(h.subs, func(sub *subscription[T]) bool { return sub == s })
+		})
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.closed = true
+		close(s.ch)
+	})
+
+	return s, cancel
+}
+
+// SetOnChange registers fn to be called after every successful mutation of the
+// [Value] (as for [Value.Subscribe]), with the replaced and the new value; an
+// unset Value is passed as the zero value. It replaces any previously set
+// function, and SetOnChange(nil) removes it. Only mutations which happen after
+// SetOnChange returns are guaranteed to call the new function.
+//
+// fn runs synchronously on the mutating goroutine, after the mutation has
+// taken effect and before the mutating method returns. Concurrent mutations call
+// fn concurrently, so it must be safe for concurrent use, and should be cheap.
+// fn must not mutate the Value itself, which would call fn again; loading the
+// Value is fine, though it may observe a later mutation than the one fn was
+// called for.
+func (v *Value[T]) SetOnChange(fn func(old, new T)) {
+	v.editHooks(func(h *hooks[T]) { h.onChange = fn })
+}
+
+// Wait blocks until the value satisfies pred, returning that value, or until
+// ctx is done, returning ctx's error. pred is called with the current value
+// straight away, so Wait returns without blocking if it is already satisfied,
+// and then again after each mutation (see [Value.Subscribe]); values which are
+// replaced before the waiter gets to see them may be skipped.
+func (v *Value[T]) Wait(ctx context.Context, pred func(T) bool) (T, error) {
+	// subscribe before the first check, so that no mutation after it is missed
+	ch, cancel := v.Subscribe()
+	defer cancel()
+
+	val := boxed[T](atomic.LoadPointer(&v.v))
+	for !pred(val) {
+		select {
+		case val = <-ch:
+		case <-ctx.Done():
+			var zeroVal T
+			return zeroVal, ctx.Err()
+		}
+	}
+
+	return val, nil
+}
+
+// WaitForValue blocks until the value equals target, as compared by
+// [Value.CompareAndSwap], returning nil, or until ctx is done, returning ctx's
+// error. As with [Value.Load], an unset Value holds the zero value, so waiting
+// for the zero value returns immediately if the Value is unset.
+func (v *Value[T]) WaitForValue(ctx context.Context, target T) error {
+	_, err := v.Wait(ctx, func(val T) bool { return equal(&val, &target) })
+	return err
+}
+
+// Observe returns a sequence of the [Value]'s values for use with range. The
+// sequence first yields the current value straight away, then the new value
+// after each mutation (see [Value.Subscribe]), and ends when ctx is done.
+//
+// Values are yielded in the order they were stored. A consumer which falls
+// behind skips intermediate values: once it is ready for the next one, it
+// receives the latest. A value may be yielded more than once, e.g. if it is
+// stored just as the sequence starts.
+//
+// Each range over the sequence subscribes separately, and the subscription is
+// cancelled when the loop ends, whether by ctx or by breaking out of it.
+func (v *Value[T]) Observe(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		// subscribe before loading, so that no mutation after it is missed
+		s, cancel := v.subscribe()
+		defer cancel()
+
+		if ctx.Err() != nil || !yield(s.current(v)) {
+			return
+		}
+
+		for {
+			select {
+			case val := <-s.ch:
+				if !yield(val) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
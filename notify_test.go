@@ -0,0 +1,550 @@
+package atomicval
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pending returns the value waiting in ch, if any, without blocking.
+func pending[T any](ch <-chan T) (val T, ok bool) {
+	select {
+	case val, ok = <-ch:
+		return val, ok
+	default:
+		return val, false
+	}
+}
+
+func TestValue_Subscribe(t *testing.T) {
+	var a Value[int]
+	ch, cancel := a.Subscribe()
+
+	_, ok := pending(ch)
+	requireEqual(t, false, ok) // nothing is delivered on subscription
+
+	a.Store(1)
+	val, ok := pending(ch)
+	requireEqual(t, true, ok)
+	requireEqual(t, 1, val)
+
+	// an equal value is still a mutation
+	a.Store(1)
+	val, ok = pending(ch)
+	requireEqual(t, true, ok)
+	requireEqual(t, 1, val)
+
+	// undelivered values are replaced by the latest one
+	a.Store(2)
+	a.Store(3)
+	val, _ = pending(ch)
+	requireEqual(t, 3, val)
+	_, ok = pending(ch)
+	requireEqual(t, false, ok)
+
+	// failed operations don't notify
+	requireEqual(t, false, a.CompareAndSwap(2, 4))
+	_, ok = pending(ch)
+	requireEqual(t, false, ok)
+
+	requireEqual(t, true, a.CompareAndSwap(3, 4))
+	val, _ = pending(ch)
+	requireEqual(t, 4, val)
+
+	a.Reset()
+	val, ok = pending(ch)
+	requireEqual(t, true, ok)
+	requireEqual(t, 0, val)
+
+	// a subscriber which never receives doesn't block mutators
+	_, cancelIdle := a.Subscribe()
+	for i := range 1000 {
+		a.Store(i)
+	}
+	val, _ = pending(ch)
+	requireEqual(t, 999, val)
+
+	cancel()
+	_, ok = <-ch
+	requireEqual(t, false, ok)
+	cancel() // idempotent
+
+	a.Store(5)
+	cancelIdle()
+	requireEqual(t, (*hooks[int])(nil), a.hooks.Load())
+
+	// without observers, mutating costs nothing extra
+	requireEqual(t, 1.0, testing.AllocsPerRun(100, func() { a.Store(6) }))
+
+	t.Run("mutators", func(t *testing.T) {
+		type point struct{ X, Y int }
+
+		tests := []struct {
+			name   string
+			mutate func(v *Value[int]) // v holds 1 beforehand, unless unset
+			want   int
+			notify bool
+			unset  bool
+		}{
+			{name: "Store", mutate: func(v *Value[int]) { v.Store(2) }, want: 2, notify: true},
+			{name: "StorePtr", mutate: func(v *Value[int]) { v.StorePtr(new(int)) }, want: 0, notify: true},
+			{name: "StorePtr(nil)", mutate: func(v *Value[int]) { v.StorePtr(nil) }, want: 0, notify: true},
+			{name: "StorePtr(nil) unset", mutate: func(v *Value[int]) { v.StorePtr(nil) }, unset: true},
+			{name: "Reset", mutate: func(v *Value[int]) { v.Reset() }, want: 0, notify: true},
+			{name: "Reset unset", mutate: func(v *Value[int]) { v.Reset() }, unset: true},
+			{name: "Take", mutate: func(v *Value[int]) { v.Take() }, want: 0, notify: true},
+			{name: "Take unset", mutate: func(v *Value[int]) { v.Take() }, unset: true},
+			{name: "Swap", mutate: func(v *Value[int]) { v.Swap(2) }, want: 2, notify: true},
+			{name: "SwapAndWasSet", mutate: func(v *Value[int]) { v.SwapAndWasSet(2) }, want: 2, notify: true},
+			{name: "CompareAndSwap", mutate: func(v *Value[int]) { v.CompareAndSwap(1, 2) }, want: 2, notify: true},
+			{name: "CompareAndSwap failed", mutate: func(v *Value[int]) { v.CompareAndSwap(0, 2) }, want: 0, notify: false},
+			{name: "CompareAndSwapFunc", mutate: func(v *Value[int]) { v.CompareAndSwapFunc(1, 2, func(a, b int) bool { return a == b }) }, want: 2, notify: true},
+			{name: "CompareAndDelete", mutate: func(v *Value[int]) { v.CompareAndDelete(1) }, want: 0, notify: true},
+			{name: "CompareAndDelete failed", mutate: func(v *Value[int]) { v.CompareAndDelete(2) }, want: 0, notify: false},
+			{name: "LoadOrStore stored", mutate: func(v *Value[int]) { v.LoadOrStore(2) }, want: 2, notify: true, unset: true},
+			{name: "LoadOrStore loaded", mutate: func(v *Value[int]) { v.LoadOrStore(2) }, want: 0, notify: false},
+			{name: "Update", mutate: func(v *Value[int]) { v.Update(func(old int) int { return old + 1 }) }, want: 2, notify: true},
+			{name: "GetAndUpdate", mutate: func(v *Value[int]) { v.GetAndUpdate(func(old int) int { return old + 1 }) }, want: 2, notify: true},
+			{name: "UpdateAndGet", mutate: func(v *Value[int]) { v.UpdateAndGet(func(old int) int { return old + 1 }) }, want: 2, notify: true},
+			{name: "StoreDuring", mutate: func(v *Value[int]) { v.StoreDuring(2, func(time.Time) bool { return true }) }, want: 2, notify: true},
+			{name: "StoreDuring rejected", mutate: func(v *Value[int]) { v.StoreDuring(2, func(time.Time) bool { return false }) }, want: 0, notify: false},
+			{name: "CompareAndSwapDiff", mutate: func(v *Value[int]) { CompareAndSwapDiff(v, 1, 2, func(_, _ int) int { return 0 }) }, want: 2, notify: true},
+			{name: "StoreAny", mutate: func(v *Value[int]) { _ = StoreAny(v, 2) }, want: 2, notify: true},
+			{name: "StoreAny rejected", mutate: func(v *Value[int]) { _ = StoreAny(v, "2") }, want: 0, notify: false},
+			{name: "UnmarshalJSON", mutate: func(v *Value[int]) { _ = v.UnmarshalJSON([]byte("2")) }, want: 2, notify: true},
+			{name: "Scan", mutate: func(v *Value[int]) { _ = v.Scan(int64(2)) }, want: 2, notify: true},
+			{name: "Atomically", mutate: func(v *Value[int]) {
+				_ = Atomically(func(tx *Tx) error {
+					TxSet(tx, v, TxGet(tx, v)+1)
+					return nil
+				})
+			}, want: 2, notify: true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				v := New(1)
+				if tt.unset {
+					v = NewUnset[int]()
+				}
+
+				ch, cancel := v.Subscribe()
+				defer cancel()
+
+				tt.mutate(v)
+				val, ok := pending(ch)
+				requireEqual(t, tt.notify, ok)
+				requireEqual(t, tt.want, val)
+			})
+		}
+
+		t.Run("embedding types", func(t *testing.T) {
+			var n Numeric[int]
+			nch, cancel := n.Subscribe()
+			defer cancel()
+			n.Add(2)
+			requireEqual(t, 2, <-nch)
+
+			var o Ordered[int]
+			och, cancel := o.Subscribe()
+			defer cancel()
+			o.StoreMax(3)
+			requireEqual(t, 3, <-och)
+			o.StoreMax(1)
+			_, ok := pending(och)
+			requireEqual(t, false, ok)
+
+			var vr Variant[point]
+			vch, cancel := vr.v.Subscribe()
+			defer cancel()
+			vr.CompareTagAndSwap(0, point{X: 1}, 1)
+			requireEqual(t, tagged[point]{tag: 1, val: point{X: 1}}, <-vch)
+		})
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		writers, subscribers := 4, 4
+		iters := 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		const final = -1
+
+		var av Value[int]
+		var wg sync.WaitGroup
+
+		chans := make([]<-chan int, subscribers)
+		cancels := make([]func(), subscribers)
+		counts := make([]int, subscribers)
+		for i := range subscribers {
+			chans[i], cancels[i] = av.Subscribe()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for val := range chans[i] {
+					counts[i]++
+					if val == final {
+						return
+					}
+				}
+			}()
+		}
+
+		// subscriptions coming and going concurrently must not disturb the others
+		churnDone := make(chan struct{})
+		go func() {
+			defer close(churnDone)
+			for range iters {
+				_, cancel := av.Subscribe()
+				runtime.Gosched()
+				cancel()
+			}
+		}()
+
+		var writerWg sync.WaitGroup
+		for w := range writers {
+			writerWg.Add(1)
+			go func() {
+				defer writerWg.Done()
+				for i := range iters {
+					switch i % 3 {
+					case 0:
+						av.Store(w*iters + i)
+					case 1:
+						av.Swap(w*iters + i)
+					case 2:
+						av.Update(func(old int) int { return old + 1 })
+					}
+				}
+			}()
+		}
+
+		writerWg.Wait()
+		<-churnDone
+		av.Store(final)
+		wg.Wait()
+
+		for i, cancel := range cancels {
+			cancel()
+			if counts[i] < 1 || counts[i] > writers*iters+1 {
+				t.Errorf("subscriber %d received %d notifications for %d mutations", i, counts[i], writers*iters+1)
+			}
+		}
+
+		requireEqual(t, (*hooks[int])(nil), av.hooks.Load())
+	})
+}
+
+func TestValue_SetOnChange(t *testing.T) {
+	type change struct{ old, new int }
+	var changes []change
+
+	var a Value[int]
+	a.SetOnChange(func(old, new int) { changes = append(changes, change{old, new}) })
+
+	a.Store(1) // from unset
+	a.Swap(2)
+	a.CompareAndSwap(1, 5) // fails
+	a.CompareAndSwap(2, 3)
+	a.Update(func(old int) int { return old * 2 })
+	a.Reset()
+	a.Take() // nothing to take
+	a.Store(0)
+	a.Take()
+	requireZero(t, Atomically(func(tx *Tx) error {
+		TxSet(tx, &a, 7)
+		return nil
+	}))
+
+	requireEqual(t, "[{0 1} {1 2} {2 3} {3 6} {6 0} {0 0} {0 0} {0 7}]", fmt.Sprint(changes))
+
+	// replaced, then removed
+	changes = nil
+	var replaced []change
+	a.SetOnChange(func(old, new int) { replaced = append(replaced, change{old, new}) })
+	a.Store(8)
+	a.SetOnChange(nil)
+	a.Store(9)
+	requireEqual(t, 0, len(changes))
+	requireEqual(t, "[{7 8}]", fmt.Sprint(replaced))
+	requireEqual(t, (*hooks[int])(nil), a.hooks.Load())
+
+	// a subscription keeps the hooks alive after the callback is removed
+	ch, cancel := a.Subscribe()
+	a.SetOnChange(func(int, int) {})
+	a.SetOnChange(nil)
+	a.Store(10)
+	requireEqual(t, 10, <-ch)
+	cancel()
+	requireEqual(t, (*hooks[int])(nil), a.hooks.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		var mu sync.Mutex
+		seen := make(map[int]int) // old -> new
+
+		var av Value[int]
+		av.SetOnChange(func(old, new int) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[old] = new
+		})
+
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					av.Update(func(old int) int { return old + 1 })
+				}
+			}()
+		}
+		wg.Wait()
+
+		// every increment was reported exactly once, with its own old and new
+		requireEqual(t, n*iters, len(seen))
+		for old := range n * iters {
+			if seen[old] != old+1 {
+				t.Fatalf("change from %d reported as to %d", old, seen[old])
+			}
+		}
+	})
+}
+
+func TestValue_Wait(t *testing.T) {
+	positive := func(n int) bool { return n > 0 }
+
+	a := New(1)
+	val, err := a.Wait(context.Background(), positive)
+	requireZero(t, err)
+	requireEqual(t, 1, val)
+
+	var b Value[int]
+	go func() {
+		b.Store(-1)
+		b.Store(0)
+		b.Store(2)
+	}()
+	val, err = b.Wait(context.Background(), positive)
+	requireZero(t, err)
+	requireEqual(t, 2, val)
+	requireEqual(t, (*hooks[int])(nil), b.hooks.Load())
+
+	var c Value[int]
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	val, err = c.Wait(ctx, positive)
+	requireEqual(t, context.DeadlineExceeded, err)
+	requireZero(t, val)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Wait(ctx, positive)
+	requireEqual(t, context.Canceled, err)
+	requireEqual(t, (*hooks[int])(nil), c.hooks.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		waiters := 4 * runtime.GOMAXPROCS(0)
+		rounds := 100
+		if testing.Short() {
+			rounds = 10
+		}
+
+		for range rounds {
+			var av Value[int]
+			var wg sync.WaitGroup
+			errs := make(chan error, waiters)
+			for range waiters {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+					defer cancel()
+					val, err := av.Wait(ctx, func(n int) bool { return n >= 100 })
+					if err == nil && val < 100 {
+						err = fmt.Errorf("woken with unsatisfying value %d", val)
+					}
+					errs <- err
+				}()
+			}
+
+			for i := range 100 {
+				av.Store(i + 1)
+				runtime.Gosched()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				requireZero(t, err)
+			}
+		}
+	})
+}
+
+func TestValue_WaitForValue(t *testing.T) {
+	type state string
+	const ready state = "ready"
+
+	var a Value[state]
+	requireZero(t, a.WaitForValue(context.Background(), "")) // unset is the zero value
+
+	a.Store(ready)
+	requireZero(t, a.WaitForValue(context.Background(), ready))
+
+	var b Value[state]
+	go func() {
+		time.Sleep(time.Millisecond)
+		b.Store("starting")
+		time.Sleep(time.Millisecond)
+		b.Store(ready)
+	}()
+	requireZero(t, b.WaitForValue(context.Background(), ready))
+
+	var c Value[state]
+	c.Store("starting")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(time.Second)
+		c.Store(ready)
+	}()
+	requireEqual(t, context.DeadlineExceeded, c.WaitForValue(ctx, ready))
+
+	// incomparable targets never match, rather than panicking
+	var d Value[any]
+	d.Store([]int{1})
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	requireEqual(t, context.DeadlineExceeded, d.WaitForValue(ctx, []int{1}))
+
+	t.Run("concurrent", func(t *testing.T) {
+		rounds := 1000
+		if testing.Short() {
+			rounds = 100
+		}
+
+		// a writer cycles through values, repeatedly passing the target, and
+		// eventually settles on it
+		var av Value[int]
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := range rounds * 10 {
+				av.Store(i % 10)
+				runtime.Gosched()
+			}
+			av.Store(7)
+		}()
+
+		for range rounds {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err := av.WaitForValue(ctx, 7)
+			cancel()
+			requireZero(t, err)
+		}
+		<-done
+	})
+}
+
+func TestValue_Observe(t *testing.T) {
+	a := New(1)
+	var got []int
+	for val := range a.Observe(context.Background()) {
+		got = append(got, val)
+		if val == 3 {
+			break
+		}
+		a.Store(val + 1)
+	}
+	requireEqual(t, "[1 2 3]", fmt.Sprint(got))
+	requireEqual(t, (*hooks[int])(nil), a.hooks.Load())
+
+	// cancelling ends the sequence
+	var b Value[int]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got = nil
+	for val := range b.Observe(ctx) {
+		got = append(got, val)
+		if len(got) == 1 {
+			// only once subscribed, so the store can't be the first value
+			go func() {
+				b.Store(1)
+				time.Sleep(time.Millisecond)
+				cancel()
+			}()
+		}
+	}
+	requireEqual(t, 0, got[0]) // the initial, unset value
+	if len(got) > 2 || len(got) == 2 && got[1] != 1 {
+		t.Fatalf("unexpected values %v", got)
+	}
+	requireEqual(t, (*hooks[int])(nil), b.hooks.Load())
+
+	// a done context yields nothing
+	for val := range b.Observe(ctx) {
+		t.Fatalf("unexpected value %d", val)
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		n := 10000
+		if testing.Short() {
+			n = 1000
+		}
+
+		// a writer stores increasing values: observers see them in order,
+		// perhaps skipping some, and eventually see the last
+		var av Value[int]
+		observers := runtime.GOMAXPROCS(0)
+		var wg sync.WaitGroup
+		errs := make(chan error, observers)
+		ready := make(chan struct{}, observers)
+		for range observers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+
+				last := -1
+				for val := range av.Observe(ctx) {
+					if last == -1 {
+						ready <- struct{}{}
+					}
+					if val < last {
+						errs <- fmt.Errorf("went back from %d to %d", last, val)
+						return
+					}
+					if last = val; val == n {
+						return
+					}
+				}
+				errs <- fmt.Errorf("ended at %d: %v", last, ctx.Err())
+			}()
+		}
+
+		for range observers {
+			<-ready
+		}
+		for i := range n {
+			av.Store(i + 1)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Fatal(err)
+		}
+		requireEqual(t, (*hooks[int])(nil), av.hooks.Load())
+	})
+}
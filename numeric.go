@@ -0,0 +1,187 @@
+package atomicval
+
+import "unsafe"
+
+// Integer is a constraint permitting any integer type.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is a constraint permitting any floating-point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is a constraint permitting any integer or floating-point type.
+type Number interface {
+	Integer | Float
+}
+
+// Numeric is a [Value] for numbers, adding arithmetic operations to the usual
+// Load/Store/Swap/CompareAndSwap (and the rest of the [Value] API).
+//
+// Like every other mutation of a [Value], each arithmetic operation publishes a
+// newly allocated value, and is implemented as a compare-and-swap loop. For
+// plain fixed-width integer counters, the types in [sync/atomic] are faster;
+// Numeric is useful when the same friendly semantics are wanted for numbers as
+// for other values, or for floating-point types, which [sync/atomic] lacks.
+//
+// The embedded field is named Value, which hides [Value.Value], so a *Numeric
+// implements [database/sql.Scanner] but not [database/sql/driver.Valuer]. To
+// write one to a database, pass &n.Value instead.
+//
+// The zero Numeric is ready for use, and holds zero. Must not be copied after
+// first use.
+type Numeric[T Number] struct {
+	Value[T]
+}
+
+// Add atomically adds delta to the current value (zero, if unset) and returns
+// the result. Integer overflow wraps around, as with the + operator.
+//
+// Floating-point addition is not associative, so for float T the result of
+// several concurrent Adds can depend on the order in which they happen to be
+// applied.
+func (n *Numeric[T]) Add(delta T) (new T) {
+	return n.UpdateAndGet(func(old T) T { return old + delta })
+}
+
+// Bitwise operations are only defined for integer types, so they are package
+// functions rather than methods of [Numeric].
+
+// And atomically replaces the value of n with its bitwise AND with mask,
+// returning the previous value (zero, if unset).
+func And[T Integer](n *Numeric[T], mask T) (old T) {
+	return n.GetAndUpdate(func(old T) T { return old & mask })
+}
+
+// Or atomically replaces the value of n with its bitwise OR with mask,
+// returning the previous value (zero, if unset).
+func Or[T Integer](n *Numeric[T], mask T) (old T) {
+	return n.GetAndUpdate(func(old T) T { return old | mask })
+}
+
+// Xor atomically replaces the value of n with its bitwise XOR with mask,
+// returning the previous value (zero, if unset).
+func Xor[T Integer](n *Numeric[T], mask T) (old T) {
+	return n.GetAndUpdate(func(old T) T { return old ^ mask })
+}
+
+// AddSaturating atomically adds delta to the value of n (zero, if unset) and
+// returns the result, which is clamped to the range of T rather than wrapping
+// around on overflow. saturated reports whether it was clamped.
+func AddSaturating[T Integer](n *Numeric[T], delta T) (new T, saturated bool) {
+	new = n.UpdateAndGet(func(old T) T {
+		var sum T
+		sum, saturated = addSaturating(old, delta)
+		return sum
+	})
+
+	return new, saturated
+}
+
+// SubSaturating is like [AddSaturating], but subtracts delta. For unsigned T,
+// this is how the value is decreased, with results below zero clamped to zero.
+func SubSaturating[T Integer](n *Numeric[T], delta T) (new T, saturated bool) {
+	new = n.UpdateAndGet(func(old T) T {
+		var diff T
+		diff, saturated = subSaturating(old, delta)
+		return diff
+	})
+
+	return new, saturated
+}
+
+func addSaturating[T Integer](x, y T) (sum T, saturated bool) {
+	sum = x + y
+	switch lo, hi := bounds[T](); {
+	case y > 0 && sum < x:
+		return hi, true
+	case y < 0 && sum > x:
+		return lo, true
+	}
+
+	return sum, false
+}
+
+func subSaturating[T Integer](x, y T) (diff T, saturated bool) {
+	diff = x - y
+	switch lo, hi := bounds[T](); {
+	case y > 0 && diff > x:
+		return lo, true
+	case y < 0 && diff < x:
+		return hi, true
+	}
+
+	return diff, false
+}
+
+// bounds returns the smallest and largest values of T.
+func bounds[T Integer]() (lo, hi T) {
+	var zero T
+	if hi = ^zero; hi > 0 {
+		return zero, hi // unsigned
+	}
+
+	lo = T(1) << (8*unsafe.Sizeof(zero) - 1)
+	return lo, ^lo
+}
+
+// compensated is the in-box representation of a [KahanSum]: the running sum,
+// and the low-order part lost from it to rounding.
+type compensated[T Float] struct {
+	sum, comp T
+}
+
+// KahanSum is an atomic floating-point accumulator which uses compensated
+// (Kahan-Babuška) summation to keep track of the rounding error of each
+// addition, so that adding many small values stays accurate where a plain
+// [Numeric.Add] would lose them to rounding.
+//
+// The sum and its compensation are stored and updated together, like the rest
+// of a [Value], with each Add a compare-and-swap loop. Compensation reduces
+// the error of the total, but doesn't eliminate it; as with Numeric, the
+// result of concurrent Adds can also depend on the order in which they happen
+// to be applied.
+//
+// The zero KahanSum is ready for use, and holds zero. Must not be copied after
+// first use.
+type KahanSum[T Float] struct {
+	v Value[compensated[T]]
+}
+
+// Add atomically adds delta to the sum, returning the new (compensated) sum.
+func (k *KahanSum[T]) Add(delta T) (sum T) {
+	c := k.v.UpdateAndGet(func(old compensated[T]) compensated[T] {
+		sum := old.sum + delta
+		comp := old.comp
+		if abs(old.sum) >= abs(delta) {
+			comp += (old.sum - sum) + delta
+		} else {
+			comp += (delta - sum) + old.sum
+		}
+		return compensated[T]{sum, comp}
+	})
+
+	return c.sum + c.comp
+}
+
+// Load returns the current (compensated) sum.
+func (k *KahanSum[T]) Load() T {
+	c := k.v.Load()
+	return c.sum + c.comp
+}
+
+// Store sets the sum to val, discarding any accumulated compensation.
+func (k *KahanSum[T]) Store(val T) {
+	k.v.Store(compensated[T]{sum: val})
+}
+
+func abs[T Float](x T) T {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,294 @@
+package atomicval
+
+import (
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNumeric_Add(t *testing.T) {
+	var a Numeric[int64]
+	requireEqual(t, int64(1), a.Add(1))
+	requireEqual(t, int64(-2), a.Add(-3))
+	requireEqual(t, int64(-2), a.Load())
+
+	// the rest of the Value API is available
+	requireEqual(t, true, a.CompareAndSwap(-2, 5))
+	requireEqual(t, int64(5), a.Swap(10))
+
+	var b Numeric[uint8]
+	b.Store(math.MaxUint8)
+	requireEqual(t, uint8(0), b.Add(1))
+
+	var c Numeric[float64]
+	requireEqual(t, 0.5, c.Add(0.5))
+	requireEqual(t, 0.25, c.Add(-0.25))
+
+	type celsius float32
+	var d Numeric[celsius]
+	requireEqual(t, celsius(21.5), d.Add(21.5))
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		var ints Numeric[int]
+		var floats Numeric[float64]
+
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					ints.Add(2)
+					floats.Add(0.5) // exactly representable, so order doesn't matter
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, 2*n*iters, ints.Load())
+		requireEqual(t, 0.5*float64(n*iters), floats.Load())
+	})
+}
+
+func TestNumeric_bitwise(t *testing.T) {
+	var a Numeric[uint8]
+	requireEqual(t, uint8(0), Or(&a, 0b1010))
+	requireEqual(t, uint8(0b1010), Or(&a, 0b0110))
+	requireEqual(t, uint8(0b1110), And(&a, 0b0111))
+	requireEqual(t, uint8(0b0110), Xor(&a, 0b1111))
+	requireEqual(t, uint8(0b1001), a.Load())
+
+	// unset is treated as zero
+	var b Numeric[int]
+	requireEqual(t, 0, And(&b, -1))
+	requireEqual(t, true, b.IsSet())
+	requireEqual(t, 0, b.Load())
+	b.Reset()
+	requireEqual(t, 0, Xor(&b, 5))
+	requireEqual(t, 5, b.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		rounds := 100
+		if testing.Short() {
+			rounds = 10
+		}
+
+		for range rounds {
+			var flags Numeric[uint64]
+			var wg sync.WaitGroup
+			for bit := range 64 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					Or(&flags, 1<<bit)
+				}()
+			}
+			wg.Wait()
+			requireEqual(t, uint64(math.MaxUint64), flags.Load())
+
+			// flip each bit twice, concurrently
+			for bit := range 128 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					Xor(&flags, 1<<(bit%64))
+				}()
+			}
+			wg.Wait()
+			requireEqual(t, uint64(math.MaxUint64), flags.Load())
+
+			requireEqual(t, uint64(math.MaxUint64), And(&flags, 0))
+			requireEqual(t, uint64(0), flags.Load())
+		}
+	})
+}
+
+func TestAddSaturating(t *testing.T) {
+	var a Numeric[int64]
+	a.Store(math.MaxInt64 - 1)
+	requireSaturating(t, int64(math.MaxInt64), false)(AddSaturating(&a, 1))
+	requireSaturating(t, int64(math.MaxInt64), true)(AddSaturating(&a, 1))
+	requireSaturating(t, int64(math.MaxInt64), true)(SubSaturating(&a, -1))
+	requireSaturating(t, int64(math.MaxInt64), false)(AddSaturating(&a, 0))
+	requireSaturating(t, int64(-1), false)(AddSaturating(&a, math.MinInt64))
+
+	a.Store(math.MinInt64 + 1)
+	requireSaturating(t, int64(math.MinInt64), false)(AddSaturating(&a, -1))
+	requireSaturating(t, int64(math.MinInt64), true)(AddSaturating(&a, -1))
+	requireSaturating(t, int64(math.MinInt64), true)(SubSaturating(&a, 1))
+	requireSaturating(t, int64(math.MinInt64), true)(AddSaturating(&a, math.MinInt64))
+	requireSaturating(t, int64(0), false)(SubSaturating(&a, math.MinInt64))
+
+	// unset is treated as zero
+	var b Numeric[uint]
+	requireSaturating(t, uint(0), true)(SubSaturating(&b, 1))
+	requireEqual(t, true, b.IsSet())
+	requireSaturating(t, uint(3), false)(AddSaturating(&b, 3))
+	requireSaturating(t, uint(0), true)(SubSaturating(&b, 4))
+	requireSaturating(t, uint(math.MaxUint), false)(AddSaturating(&b, math.MaxUint))
+	requireSaturating(t, uint(math.MaxUint), true)(AddSaturating(&b, 1))
+
+	var c Numeric[int8]
+	requireSaturating(t, int8(100), false)(AddSaturating(&c, 100))
+	requireSaturating(t, int8(127), true)(AddSaturating(&c, 100))
+	requireSaturating(t, int8(0), false)(SubSaturating(&c, 127))
+	requireSaturating(t, int8(-127), false)(SubSaturating(&c, 127))
+	requireSaturating(t, int8(-128), true)(SubSaturating(&c, 127))
+	requireSaturating(t, int8(0), false)(SubSaturating(&c, -128))
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		const limit = math.MaxUint16
+		var quota Numeric[uint16]
+		var saturated atomic.Int64
+
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					if _, sat := AddSaturating(&quota, limit/100); sat {
+						saturated.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		// the first 100 adds fit, and every later one clamps
+		requireEqual(t, uint16(limit), quota.Load())
+		requireEqual(t, int64(n*iters-100), saturated.Load())
+	})
+}
+
+func requireSaturating[T comparable](t *testing.T, want T, wantSaturated bool) func(T, bool) {
+	t.Helper()
+	return func(got T, saturated bool) {
+		t.Helper()
+		requireEqual(t, want, got)
+		requireEqual(t, wantSaturated, saturated)
+	}
+}
+
+func BenchmarkNumeric_Add(b *testing.B) {
+	const paralellism = 100
+
+	b.Run("Numeric", func(b *testing.B) {
+		var av Numeric[int64]
+
+		b.SetParallelism(paralellism)
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				runtime.KeepAlive(av.Add(1))
+			}
+		})
+	})
+
+	b.Run("stdlib_Int64", func(b *testing.B) {
+		var av atomic.Int64
+
+		b.SetParallelism(paralellism)
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				runtime.KeepAlive(av.Add(1))
+			}
+		})
+	})
+
+	b.Run("mutexValue", func(b *testing.B) {
+		var av mutexValue[int64]
+
+		b.SetParallelism(paralellism)
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				// hand-written, as mutexValue has no Add
+				av.mu.Lock()
+				av.inner++
+				runtime.KeepAlive(av.inner)
+				av.mu.Unlock()
+			}
+		})
+	})
+}
+
+func TestKahanSum(t *testing.T) {
+	var a KahanSum[float64]
+	requireEqual(t, 0.0, a.Load())
+	requireEqual(t, 1.5, a.Add(1.5))
+	requireEqual(t, 1.0, a.Add(-0.5))
+	a.Store(3)
+	requireEqual(t, 3.0, a.Load())
+
+	// a large value swamps small ones in a plain sum, but not in a
+	// compensated one
+	var b KahanSum[float64]
+	b.Add(1)
+	b.Add(1e100)
+	b.Add(1)
+	b.Add(-1e100)
+	requireEqual(t, 2.0, b.Load())
+
+	t.Run("many tiny values", func(t *testing.T) {
+		n := 1000000
+		if testing.Short() {
+			n = 100000
+		}
+
+		const start, delta = 1.0, 1e-10
+		want := new(big.Float).SetPrec(256).SetFloat64(delta)
+		want.Mul(want, new(big.Float).SetInt64(int64(n)))
+		want.Add(want, big.NewFloat(start))
+		errorOf := func(got float64) float64 {
+			diff := new(big.Float).Sub(big.NewFloat(got), want)
+			f, _ := diff.Float64()
+			return math.Abs(f)
+		}
+
+		var plain Numeric[float64]
+		var kahan KahanSum[float64]
+		plain.Store(start)
+		kahan.Store(start)
+
+		var wg sync.WaitGroup
+		workers := 4
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range n / workers {
+					plain.Add(delta)
+					kahan.Add(delta)
+				}
+			}()
+		}
+		wg.Wait()
+
+		plainErr, kahanErr := errorOf(plain.Load()), errorOf(kahan.Load())
+		if kahanErr >= plainErr {
+			t.Fatalf("compensated error %g not below plain error %g", kahanErr, plainErr)
+		}
+		// rounding the exact total to float64 is the best possible result
+		if exact, _ := want.Float64(); kahanErr > errorOf(exact) {
+			t.Fatalf("compensated error %g above rounding error %g", kahanErr, errorOf(exact))
+		}
+	})
+}
@@ -0,0 +1,40 @@
+//go:build go1.24
+
+package atomicval
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValue_IsZero(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, true, a.IsZero())
+	a.Store(0)
+	requireEqual(t, false, a.IsZero())
+	a.Reset()
+	requireEqual(t, true, a.IsZero())
+
+	type config struct {
+		Name    Value[string] `json:"name,omitzero"`
+		Limit   Value[int]    `json:"limit,omitzero"`
+		Timeout Value[int]    `json:"timeout,omitzero"`
+		Tags    Value[*[]string]
+	}
+
+	var src config
+	src.Name.Store("a")
+	src.Limit.Store(0) // set, if to the zero value
+
+	b, err := json.Marshal(&src)
+	requireZero(t, err)
+	requireEqual(t, `{"name":"a","limit":0,"Tags":null}`, string(b))
+
+	// omitted fields stay unset when decoded
+	var dst config
+	requireZero(t, json.Unmarshal(b, &dst))
+	requireEqual(t, "a", dst.Name.Load())
+	requireEqual(t, true, dst.Limit.IsSet())
+	requireEqual(t, false, dst.Timeout.IsSet())
+	requireEqual(t, false, dst.Tags.IsSet())
+}
@@ -0,0 +1,91 @@
+package atomicval
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Option holds either a value of type T ("some") or nothing ("none"). The zero
+// Option is none.
+type Option[T any] struct {
+	val T
+	ok  bool
+}
+
+// Some returns an [Option] holding val.
+func Some[T any](val T) Option[T] { return Option[T]{val: val, ok: true} }
+
+// Get returns the held value and true, or the zero value and false if o is
+// none.
+func (o Option[T]) Get() (val T, ok bool) { return o.val, o.ok }
+
+// OrElse returns the held value, or def if o is none.
+func (o Option[T]) OrElse(def T) T {
+	if !o.ok {
+		return def
+	}
+
+	return o.val
+}
+
+// IsSome reports whether o holds a value.
+func (o Option[T]) IsSome() bool { return o.ok }
+
+// Peek returns the current value and true, or the zero value and false if no
+// value has been set. Unlike calling [Value.IsSet] and then [Value.Load], both
+// results come from a single load, so they are always consistent with each
+// other, and a [Value] explicitly holding the zero value returns true.
+func (v *Value[T]) Peek() (val T, ok bool) {
+	dp := atomic.LoadPointer(&v.v)
+	if dp == nil {
+		return val, false
+	}
+
+	return *(*T)(dp), true
+}
+
+// LoadOption returns the current value as an [Option], which is none if no
+// value has been set. Unlike [Value.Load], this distinguishes an unset
+// [Value] from one holding the zero value.
+func (v *Value[T]) LoadOption() Option[T] {
+	val, ok := v.Peek()
+	return Option[T]{val: val, ok: ok}
+}
+
+// LoadOrDefault returns the current value, or def if no value has been set.
+// It is equivalent to v.LoadOption().OrElse(def): a [Value] explicitly holding
+// the zero value returns that, not def.
+func (v *Value[T]) LoadOrDefault(def T) T {
+	dp := atomic.LoadPointer(&v.v)
+	if dp == nil {
+		return def
+	}
+
+	return *(*T)(dp)
+}
+
+// CompareAndSwapOption is like [Value.CompareAndSwap], but distinguishes an
+// unset [Value] from one holding the zero value: if old is none, the swap only
+// happens if the Value is unset, and if old is some, only if the Value is set
+// to a value equal to old's. This matters for e.g. pointer T, where
+// CompareAndSwap(nil, p) can't tell a Value which was never set from one
+// explicitly set to nil, and succeeds for both.
+func (v *Value[T]) CompareAndSwapOption(old Option[T], new T) (swapped bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		dp := atomic.LoadPointer(&v.v)
+		if old.ok != (dp != nil) || old.ok && !equal((*T)(dp), &old.val) {
+			return false
+		}
+
+		if box == nil {
+			box = unsafe.Pointer(&[1]T{new})
+		}
+
+		// as in [Value.CompareAndSwap], compare again if the value was
+		// replaced in the meantime
+		if v.compareAndSwapBox(dp, box) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package atomicval
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOption(t *testing.T) {
+	var none Option[int]
+	val, ok := none.Get()
+	requireZero(t, val)
+	requireEqual(t, false, ok)
+	requireEqual(t, false, none.IsSome())
+	requireEqual(t, 7, none.OrElse(7))
+
+	some := Option[int]{val: 3, ok: true}
+	val, ok = some.Get()
+	requireEqual(t, 3, val)
+	requireEqual(t, true, ok)
+	requireEqual(t, true, some.IsSome())
+	requireEqual(t, 3, some.OrElse(7))
+}
+
+func TestValue_Peek(t *testing.T) {
+	var a Value[int]
+	val, ok := a.Peek()
+	requireZero(t, val)
+	requireEqual(t, false, ok)
+
+	a.Store(0)
+	val, ok = a.Peek()
+	requireZero(t, val)
+	requireEqual(t, true, ok)
+
+	a.Store(5)
+	val, ok = a.Peek()
+	requireEqual(t, 5, val)
+	requireEqual(t, true, ok)
+
+	a.Reset()
+	_, ok = a.Peek()
+	requireEqual(t, false, ok)
+
+	var w Value[io.Writer]
+	w.Store(nil)
+	wval, ok := w.Peek()
+	requireZero(t, wval)
+	requireEqual(t, true, ok)
+
+	t.Run("concurrent", func(t *testing.T) {
+		iters := 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		// presence and value come from the same load: never set and zero, nor
+		// unset and nonzero, although the writer alternates between them
+		var av Value[int]
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range iters {
+				av.Store(1)
+				av.Reset()
+				runtime.Gosched()
+			}
+		}()
+
+		for range iters {
+			if val, ok := av.Peek(); ok != (val == 1) {
+				t.Fatalf("peeked %d, %t", val, ok)
+			}
+			runtime.Gosched()
+		}
+		<-done
+	})
+}
+
+func TestValue_LoadOption(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, Option[int]{}, a.LoadOption())
+	requireEqual(t, -1, a.LoadOption().OrElse(-1))
+
+	// set to zero is still some
+	a.Store(0)
+	requireEqual(t, true, a.LoadOption().IsSome())
+	requireEqual(t, 0, a.LoadOption().OrElse(-1))
+
+	a.Store(5)
+	val, ok := a.LoadOption().Get()
+	requireEqual(t, 5, val)
+	requireEqual(t, true, ok)
+
+	var b Value[io.Writer]
+	requireEqual(t, false, b.LoadOption().IsSome())
+	b.Store(nil)
+	val2, ok := b.LoadOption().Get()
+	requireZero(t, val2)
+	requireEqual(t, true, ok)
+
+	// first CompareAndSwap from unset counts as set
+	var c Value[string]
+	c.CompareAndSwap("", "")
+	requireEqual(t, Option[string]{ok: true}, c.LoadOption())
+}
+
+func TestSome(t *testing.T) {
+	val, ok := Some(0).Get()
+	requireZero(t, val)
+	requireEqual(t, true, ok)
+	requireEqual(t, Option[int]{val: 0, ok: true}, Some(0))
+}
+
+func TestValue_LoadOrDefault(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, 7, a.LoadOrDefault(7))
+
+	a.Store(0)
+	requireEqual(t, 0, a.LoadOrDefault(7))
+	a.Store(3)
+	requireEqual(t, 3, a.LoadOrDefault(7))
+
+	a.Reset()
+	requireEqual(t, 7, a.LoadOrDefault(7))
+
+	var w Value[io.Writer]
+	requireEqual(t, io.Discard, w.LoadOrDefault(io.Discard))
+	w.Store(nil)
+	requireZero(t, w.LoadOrDefault(io.Discard))
+
+	t.Run("concurrent", func(t *testing.T) {
+		// a racing store is seen either entirely or not at all
+		iters := 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		for range iters {
+			var av Value[int]
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				av.Store(0)
+			}()
+			if got := av.LoadOrDefault(-1); got != 0 && got != -1 {
+				t.Fatalf("unexpected value %d", got)
+			}
+			<-done
+		}
+	})
+}
+
+func TestValue_CompareAndSwapOption(t *testing.T) {
+	p, q := new(int), new(int)
+	none := Option[*int]{}
+
+	// unset vs explicitly nil, against none, some(nil) and some(non-nil)
+	var unset Value[*int]
+	requireEqual(t, false, unset.CompareAndSwapOption(Some[*int](nil), q))
+	requireEqual(t, false, unset.CompareAndSwapOption(Some(p), q))
+	requireEqual(t, false, unset.IsSet())
+	requireEqual(t, true, unset.CompareAndSwapOption(none, q))
+	requireEqual(t, q, unset.Load())
+
+	storedNil := New[*int](nil)
+	requireEqual(t, false, storedNil.CompareAndSwapOption(none, q))
+	requireEqual(t, false, storedNil.CompareAndSwapOption(Some(p), q))
+	requireEqual(t, (*int)(nil), storedNil.Load())
+	requireEqual(t, true, storedNil.CompareAndSwapOption(Some[*int](nil), q))
+	requireEqual(t, q, storedNil.Load())
+
+	// CompareAndSwap, by contrast, can't tell the two apart
+	requireEqual(t, true, NewUnset[*int]().CompareAndSwap(nil, q))
+	requireEqual(t, true, New[*int](nil).CompareAndSwap(nil, q))
+
+	// set to non-nil
+	storedP := New(p)
+	requireEqual(t, false, storedP.CompareAndSwapOption(none, q))
+	requireEqual(t, false, storedP.CompareAndSwapOption(Some[*int](nil), q))
+	requireEqual(t, false, storedP.CompareAndSwapOption(Some(q), q))
+	requireEqual(t, true, storedP.CompareAndSwapOption(Some(p), q))
+	requireEqual(t, q, storedP.Load())
+
+	// incomparable dynamic types never match
+	a := New[any]([]int{1})
+	requireEqual(t, false, a.CompareAndSwapOption(Some[any]([]int{1}), 2))
+
+	t.Run("concurrent", func(t *testing.T) {
+		// of many goroutines racing to swap from unset, exactly one succeeds
+		n := 4 * runtime.GOMAXPROCS(0)
+		var av Value[*int]
+		var wins atomic.Int32
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if av.CompareAndSwapOption(Option[*int]{}, nil) {
+					wins.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, int32(1), wins.Load())
+		requireEqual(t, true, av.IsSet())
+	})
+}
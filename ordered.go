@@ -0,0 +1,59 @@
+package atomicval
+
+import (
+	"cmp"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Ordered is a [Value] for ordered types, adding operations which only store
+// a candidate value if it compares above or below the current one, e.g. to
+// maintain a high-water mark.
+//
+// Comparisons follow [cmp.Less], so for floating-point T a NaN orders before
+// every other value.
+//
+// The embedded field is named Value, which hides [Value.Value], so a *Ordered
+// implements [database/sql.Scanner] but not [database/sql/driver.Valuer]. To
+// write one to a database, pass &o.Value instead.
+//
+// The zero Ordered is ready for use, and holds the zero value. Must not be
+// copied after first use.
+type Ordered[T cmp.Ordered] struct {
+	Value[T]
+}
+
+// StoreMax stores candidate if it is strictly greater than the current value
+// (or the zero value, if unset), returning whether the value was updated.
+func (o *Ordered[T]) StoreMax(candidate T) (updated bool) {
+	return o.storeIf(candidate, func(cur T) bool { return cmp.Less(cur, candidate) })
+}
+
+// StoreMin stores candidate if it is strictly less than the current value
+// (or the zero value, if unset), returning whether the value was updated.
+func (o *Ordered[T]) StoreMin(candidate T) (updated bool) {
+	return o.storeIf(candidate, func(cur T) bool { return cmp.Less(candidate, cur) })
+}
+
+func (o *Ordered[T]) storeIf(candidate T, replace func(cur T) bool) (updated bool) {
+	var box *[1]T // allocated once, reused across attempts until published
+	for {
+		var cur T
+		dp := atomic.LoadPointer(&o.v)
+		if dp != nil {
+			cur = *(*T)(dp)
+		}
+
+		if !replace(cur) {
+			return false
+		}
+
+		if box == nil {
+			box = &[1]T{candidate}
+		}
+
+		if o.compareAndSwapBox(dp, unsafe.Pointer(box)) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package atomicval
+
+import (
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestOrdered_StoreMax(t *testing.T) {
+	var a Ordered[int]
+	requireEqual(t, false, a.StoreMax(0)) // unset compares as zero
+	requireEqual(t, false, a.IsSet())
+	requireEqual(t, false, a.StoreMax(-1))
+	requireEqual(t, true, a.StoreMax(3))
+	requireEqual(t, false, a.StoreMax(3))
+	requireEqual(t, false, a.StoreMax(2))
+	requireEqual(t, 3, a.Load())
+
+	var s Ordered[string]
+	requireEqual(t, true, s.StoreMax("b"))
+	requireEqual(t, true, s.StoreMax("c"))
+	requireEqual(t, false, s.StoreMax("a"))
+	requireEqual(t, "c", s.Load())
+
+	var f Ordered[float64]
+	f.Store(math.NaN())
+	requireEqual(t, true, f.StoreMax(-1))
+	requireEqual(t, false, f.StoreMax(math.NaN()))
+	requireEqual(t, -1.0, f.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		var hwm Ordered[uint64]
+		maxes := make([]uint64, n)
+
+		var wg sync.WaitGroup
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					c := rand.Uint64()
+					maxes[i] = max(maxes[i], c)
+					hwm.StoreMax(c)
+				}
+			}()
+		}
+		wg.Wait()
+
+		var want uint64
+		for _, m := range maxes {
+			want = max(want, m)
+		}
+		requireEqual(t, want, hwm.Load())
+	})
+}
+
+func TestOrdered_StoreMin(t *testing.T) {
+	var a Ordered[int]
+	requireEqual(t, false, a.StoreMin(0))
+	requireEqual(t, false, a.StoreMin(1))
+	requireEqual(t, true, a.StoreMin(-3))
+	requireEqual(t, false, a.StoreMin(-3))
+	requireEqual(t, -3, a.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		var lwm Ordered[int64]
+		mins := make([]int64, n)
+
+		var wg sync.WaitGroup
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					c := rand.Int64() - math.MaxInt64/2
+					mins[i] = min(mins[i], c)
+					lwm.StoreMin(c)
+				}
+			}()
+		}
+		wg.Wait()
+
+		var want int64
+		for _, m := range mins {
+			want = min(want, m)
+		}
+		requireEqual(t, want, lwm.Load())
+	})
+}
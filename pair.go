@@ -0,0 +1,86 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ConsistentPair coordinates updates to two independent Values so that
+// readers going through the pair always observe a matching combination, i.e.
+// both values from the same [ConsistentPair.Set]. It is a seqlock: Set
+// serializes writers and marks an update as in progress, and Load retries if
+// an update overlapped its reads. Loads never block writers, take no locks, and
+// do not allocate.
+//
+// The guarantee only covers access through the pair. Stores made directly to
+// either underlying Value are not coordinated, and a direct Load of one of
+// them can observe a Set that is only half complete.
+//
+// Must not be copied after first use.
+type ConsistentPair[A, B comparable] struct {
+	_ noCopy
+
+	mu  sync.Mutex    // serializes writers
+	seq atomic.Uint64 // odd while a Set is in progress
+
+	a *Value[A]
+	b *Value[B]
+}
+
+// Combine returns a [ConsistentPair] coordinating a and b, which must be
+// non-nil.
+func Combine[A, B comparable](a *Value[A], b *Value[B]) *ConsistentPair[A, B] {
+	return &ConsistentPair[A, B]{a: a, b: b}
+}
+
+// Set stores a and b such that no [ConsistentPair.Load] observes one without
+// the other.
+func (p *ConsistentPair[A, B]) Set(a A, b B) {
+	p.mu.Lock()
+	p.seq.Add(1)
+	p.a.Store(a)
+	p.b.Store(b)
+	p.seq.Add(1)
+	p.mu.Unlock()
+}
+
+// Load returns the values of both underlying Values as stored by the same
+// [ConsistentPair.Set] (or their initial state, if no Set has completed).
+func (p *ConsistentPair[A, B]) Load() (a A, b B) {
+	for {
+		seq := p.seq.Load()
+		if seq&1 == 0 {
+			a, b = p.a.Load(), p.b.Load()
+			if p.seq.Load() == seq {
+				return a, b
+			}
+		}
+
+		runtime.Gosched()
+	}
+}
+
+// Apply2 compare-and-swaps a from oldA to newA, then b from oldB to newB, with
+// the semantics of [Value.CompareAndSwap], reporting whether both succeeded. If
+// the first fails, b is left alone. If the second fails, a is swapped back from
+// newA to oldA, and Apply2 returns false.
+//
+// This is NOT an atomic update of both Values: they are two separate
+// compare-and-swaps, and between them (and until a rollback) other goroutines
+// can observe a changed but not b. The rollback is best-effort, too: if
+// another goroutine changes a in the meantime, a keeps that change rather than
+// reverting to oldA. Apply2 is only appropriate when no other writer competes
+// for a; for real atomicity across Values, use [Atomically].
+func Apply2[A, B comparable](a *Value[A], b *Value[B], oldA, newA A, oldB, newB B) (applied bool) {
+	if !a.CompareAndSwap(oldA, newA) {
+		return false
+	}
+
+	if !b.CompareAndSwap(oldB, newB) {
+		a.CompareAndSwap(newA, oldA)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,143 @@
+package atomicval
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestConsistentPair(t *testing.T) {
+	var a Value[int]
+	var b Value[string]
+	p := Combine(&a, &b)
+
+	x, y := p.Load()
+	requireZero(t, x)
+	requireZero(t, y)
+
+	p.Set(1, "1")
+	x, y = p.Load()
+	requireEqual(t, 1, x)
+	requireEqual(t, "1", y)
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, "1", b.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		var a Value[int]
+		var b Value[string]
+		p := Combine(&a, &b)
+		p.Set(0, "0")
+
+		writers, readers := 4, 4*runtime.GOMAXPROCS(0)
+		iters := 20000
+		if testing.Short() {
+			iters = 2000
+		}
+
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		failChan := make(chan error, readers)
+
+		for range readers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					x, y := p.Load()
+					if strconv.Itoa(x) != y {
+						failChan <- fmt.Errorf("torn read: %d, %q", x, y)
+						return
+					}
+				}
+			}()
+		}
+
+		var writerWg sync.WaitGroup
+		for w := range writers {
+			writerWg.Add(1)
+			go func() {
+				defer writerWg.Done()
+				for i := range iters {
+					n := w*iters + i
+					p.Set(n, strconv.Itoa(n))
+				}
+			}()
+		}
+
+		writerWg.Wait()
+		close(done)
+		wg.Wait()
+		close(failChan)
+
+		for err := range failChan {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestApply2(t *testing.T) {
+	var a Value[int]
+	var b Value[string]
+	requireEqual(t, true, Apply2(&a, &b, 0, 1, "", "one"))
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, "one", b.Load())
+
+	// a doesn't match: nothing changes
+	requireEqual(t, false, Apply2(&a, &b, 0, 2, "one", "two"))
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, "one", b.Load())
+
+	// b doesn't match: a is rolled back
+	requireEqual(t, false, Apply2(&a, &b, 1, 2, "", "two"))
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, "one", b.Load())
+
+	t.Run("interference", func(t *testing.T) {
+		// another writer changes b after a has been swapped: the intermediate
+		// state is observable, and a is rolled back
+		var a Value[int]
+		var b Value[string]
+		var observed []string
+		a.SetOnChange(func(old, new int) {
+			if new == 1 {
+				observed = append(observed, b.Load())
+				b.Store("interfering")
+			}
+		})
+
+		requireEqual(t, false, Apply2(&a, &b, 0, 1, "", "one"))
+		requireEqual(t, 0, a.Load())
+		requireEqual(t, "interfering", b.Load())
+		requireEqual(t, 1, len(observed))
+		requireEqual(t, "", observed[0])
+	})
+
+	t.Run("rollback is best-effort", func(t *testing.T) {
+		// another writer changes a before the rollback: its change is kept
+		var a Value[int]
+		var b Value[string]
+		a.SetOnChange(func(old, new int) {
+			if new == 1 {
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					a.Store(99)
+				}()
+				<-done
+				b.Store("interfering")
+			}
+		})
+
+		requireEqual(t, false, Apply2(&a, &b, 0, 1, "", "one"))
+		requireEqual(t, 99, a.Load())
+		requireEqual(t, "interfering", b.Load())
+	})
+}
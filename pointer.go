@@ -0,0 +1,35 @@
+package atomicval
+
+import "sync/atomic"
+
+// Pointer is an atomic *T. It offers the core subset of the methods of [Value]
+// (Load, Store, Swap and CompareAndSwap), but stores the pointer directly in
+// the atomic word, as [atomic.Pointer] does, rather than in a separately
+// allocated box. Store and Swap therefore never allocate, and Load involves no
+// extra indirection, which makes Pointer preferable to Value[*T] where those
+// methods suffice.
+//
+// Pointers are compared by identity, so nil needs no special handling: an
+// unset Pointer holds nil, and CompareAndSwap(nil, p) on it succeeds.
+//
+// The zero Pointer is ready for use. Must not be copied after first use.
+type Pointer[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// Load returns the pointer set by the most recent mutation, or nil if none
+// has been set.
+func (p *Pointer[T]) Load() *T { return p.p.Load() }
+
+// Store sets the pointer to val, which may be nil.
+func (p *Pointer[T]) Store(val *T) { p.p.Store(val) }
+
+// Swap stores new and returns the previous pointer, or nil if none had been
+// set.
+func (p *Pointer[T]) Swap(new *T) (old *T) { return p.p.Swap(new) }
+
+// CompareAndSwap stores new if the current pointer is old, reporting whether
+// it did so. Only the pointers are compared, not the values they point to.
+func (p *Pointer[T]) CompareAndSwap(old, new *T) (swapped bool) {
+	return p.p.CompareAndSwap(old, new)
+}
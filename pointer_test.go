@@ -0,0 +1,126 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPointer(t *testing.T) {
+	var a Pointer[ex]
+	requireEqual(t, (*ex)(nil), a.Load())
+
+	x, y := &ex{a: 1}, &ex{a: 1}
+	requireEqual(t, false, a.CompareAndSwap(x, y))
+	requireEqual(t, true, a.CompareAndSwap(nil, x))
+	requireEqual(t, x, a.Load())
+
+	// compared by identity, not by the pointed-to value
+	requireEqual(t, false, a.CompareAndSwap(y, nil))
+	requireEqual(t, x, a.Swap(y))
+	requireEqual(t, y, a.Load())
+
+	a.Store(nil)
+	requireEqual(t, (*ex)(nil), a.Load())
+	requireEqual(t, (*ex)(nil), a.Swap(x))
+	requireEqual(t, true, a.CompareAndSwap(x, nil))
+	requireEqual(t, (*ex)(nil), a.Load())
+
+	t.Run("concurrent swaps", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		// every pointer swapped in is swapped out exactly once, by a later
+		// Swap or the final Load
+		var p Pointer[int]
+		seen := make([][]*int, n)
+		var wg sync.WaitGroup
+		for g := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range iters {
+					if old := p.Swap(&i); old != nil {
+						seen[g] = append(seen[g], old)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		unique := map[*int]bool{p.Load(): true}
+		for _, s := range seen {
+			for _, old := range s {
+				if unique[old] {
+					t.Fatalf("pointer %p swapped out twice", old)
+				}
+				unique[old] = true
+			}
+		}
+		requireEqual(t, n*iters, len(unique))
+	})
+
+	t.Run("no allocations", func(t *testing.T) {
+		var p Pointer[int]
+		v := new(int)
+		allocs := testing.AllocsPerRun(100, func() {
+			p.Store(v)
+			p.Swap(v)
+			p.CompareAndSwap(v, v)
+		})
+		requireEqual(t, 0.0, allocs)
+	})
+}
+
+func BenchmarkPointer(b *testing.B) {
+	const paralellism = 100
+
+	type tt [32]uint8
+
+	x := &tt{1}
+
+	b.Run("Pointer", func(b *testing.B) {
+		var av Pointer[tt]
+
+		b.SetParallelism(paralellism)
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				av.Swap(x)
+				runtime.KeepAlive(av.Load())
+			}
+		})
+	})
+
+	b.Run("Value", func(b *testing.B) {
+		var av Value[*tt]
+
+		b.SetParallelism(paralellism)
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				av.Swap(x)
+				runtime.KeepAlive(av.Load())
+			}
+		})
+	})
+
+	b.Run("stdlib_pointer", func(b *testing.B) {
+		var av atomic.Pointer[tt]
+
+		b.SetParallelism(paralellism)
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				av.Swap(x)
+				runtime.KeepAlive(av.Load())
+			}
+		})
+	})
+}
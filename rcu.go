@@ -0,0 +1,68 @@
+//go:build go1.24
+
+package atomicval
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ReplaceRCU stores new, and arranges for onRetire to be called with the
+// replaced value once no reader can still be referring to the Value's copy of
+// it, in the manner of read-copy-update. This allows resources tied to a value
+// (e.g. a file handle in a config) to be released without pulling them out
+// from under a reader. It reports whether onRetire will be called.
+//
+// The grace period is tracked by the garbage collector: onRetire runs, on a
+// goroutine of its own, after the Value's internal copy of the old value has
+// become unreachable. Only readers holding a reference to that copy, obtained
+// by [Value.LoadPtr], [Value.Snapshot] or [Value.LoadRef], are protected, for
+// as long as they hold it. A plain [Value.Load] returns a separate copy of the
+// value, which is not tracked: a reader which uses the value's resources must
+// not read it with Load, or onRetire may release them while still in use.
+//
+// Since retirement waits for a garbage collection, onRetire may run arbitrarily
+// late, and is not guaranteed to run before the program exits. It runs exactly
+// once for each retired value. ReplaceRCU returns false, and onRetire is never
+// called, if the Value was unset, or if its copy of the old value was adopted
+// by [Value.StorePtr] from memory the garbage collector doesn't manage (e.g. a
+// package-level variable), which is never reclaimed.
+//
+// Storing the same pointer with StorePtr more than once also defeats the
+// tracking, since the copies are then one and the same. Likewise, the copy made
+// by other methods of a small value free of pointers may share its memory with
+// unrelated allocations, and only be retired once they are all unreachable;
+// values which are themselves stored by ReplaceRCU don't have this problem.
+//
+// ReplaceRCU is only available when building with Go 1.24 or later.
+func (v *Value[T]) ReplaceRCU(new T, onRetire func(old T)) (tracked bool) {
+	box := unsafe.Pointer(&rcuBox[T]{val: new})
+	old := atomic.SwapPointer(&v.v, box)
+	v.changed(old, box)
+	if old == nil {
+		return false
+	}
+
+	// a zero-size value has no state for a reader to be using, and its box may
+	// be shared with other zero-size allocations, which are never collected
+	if unsafe.Sizeof(new) == 0 {
+		go onRetire(*(*T)(old))
+		return true
+	}
+
+	// the cleanup must not keep the box alive, so it gets its own copy of the
+	// value, which is only handed out once the box is gone. The runtime
+	// returns the zero Cleanup, which does nothing, for memory it won't free
+	retired := *(*T)(old)
+	c := runtime.AddCleanup((*[1]T)(old), func(retired T) { onRetire(retired) }, retired)
+	return c != runtime.Cleanup{}
+}
+
+// rcuBox is allocated in place of a [1]T by ReplaceRCU. The pointer keeps it out
+// of the runtime's tiny allocator, whose blocks are shared by unrelated small
+// allocations and only freed together.
+type rcuBox[T any] struct {
+	val T
+	_   *byte
+}
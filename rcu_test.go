@@ -0,0 +1,130 @@
+//go:build go1.24
+
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// awaitRetired collects garbage until ch delivers a value, failing the test if
+// that takes too long.
+func awaitRetired[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case val := <-ch:
+			return val
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	t.Fatal("value not retired")
+	panic("unreachable")
+}
+
+// rcuStatic is adopted by a Value with StorePtr, as memory which isn't
+// allocated on the heap.
+var rcuStatic = 1
+
+func TestValue_ReplaceRCU(t *testing.T) {
+	type config struct {
+		name string
+		data [4]*int
+	}
+
+	retired := make(chan string, 10)
+	onRetire := func(old config) { retired <- old.name }
+
+	var a Value[config]
+	requireEqual(t, false, a.ReplaceRCU(config{name: "a"}, onRetire)) // unset: nothing to retire
+	requireEqual(t, true, a.ReplaceRCU(config{name: "b"}, onRetire))
+	requireEqual(t, "a", awaitRetired(t, retired))
+	requireEqual(t, "b", a.Load().name)
+
+	// a reader holding a snapshot delays retirement until it lets go
+	s := a.Snapshot()
+	a.ReplaceRCU(config{name: "c"}, onRetire)
+	for range 3 {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case name := <-retired:
+		t.Fatalf("%s retired while referenced", name)
+	default:
+	}
+	requireEqual(t, "b", s.Ptr().name)
+	runtime.KeepAlive(s)
+	requireEqual(t, "b", awaitRetired(t, retired))
+
+	// values replaced other than by ReplaceRCU aren't tracked
+	a.Store(config{name: "d"})
+	a.ReplaceRCU(config{name: "e"}, onRetire)
+	requireEqual(t, "d", awaitRetired(t, retired))
+
+	// zero-size values retire straight away
+	var z Value[struct{}]
+	done := make(chan struct{}, 1)
+	z.Store(struct{}{})
+	z.ReplaceRCU(struct{}{}, func(struct{}) { done <- struct{}{} })
+	awaitRetired(t, done)
+
+	t.Run("not heap-allocated", func(t *testing.T) {
+		// a package-level variable is never reclaimed, so can't be retired
+		var av Value[int]
+		av.StorePtr(&rcuStatic)
+		requireEqual(t, false, av.ReplaceRCU(2, func(int) { t.Error("retired a static value") }))
+		requireEqual(t, 2, av.Load())
+
+		// the value stored in its place can be, as usual
+		retired := make(chan int, 1)
+		requireEqual(t, true, av.ReplaceRCU(3, func(old int) { retired <- old }))
+		requireEqual(t, 2, awaitRetired(t, retired))
+	})
+
+	t.Run("exactly once", func(t *testing.T) {
+		n := 100
+		var mu sync.Mutex
+		counts := make(map[int]int)
+		var wg sync.WaitGroup
+		wg.Add(n)
+
+		// every value is stored by ReplaceRCU, the last one is never retired
+		var av Value[int]
+		for i := range n + 1 {
+			av.ReplaceRCU(i, func(old int) {
+				mu.Lock()
+				counts[old]++
+				mu.Unlock()
+				wg.Done()
+			})
+		}
+
+		finished := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(finished)
+		}()
+		awaitRetired(t, finished)
+
+		// give any duplicate a chance to show up
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requireEqual(t, n, len(counts))
+		for old, count := range counts {
+			requireEqual(t, 1, count)
+			if old < 0 || old >= n {
+				t.Fatalf("retired value %d was never replaced", old)
+			}
+		}
+	})
+}
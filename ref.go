@@ -0,0 +1,42 @@
+package atomicval
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Ref identifies the exact value held by a [Value] at some point, as returned
+// by [Value.LoadRef], independently of what that value is. Every mutation of a
+// Value publishes a new internal copy of the value, even when storing a value
+// equal to the current one, so a Ref only matches until the next mutation.
+//
+// A Ref keeps its copy of the value alive, so that its memory can't be reused
+// for a later one while the Ref is held, and matching it is therefore free of
+// ABA problems. The exception is [Value.StorePtr], which adopts the caller's
+// pointer as the internal copy: storing the same pointer twice makes a Ref
+// from the first store match the second.
+//
+// The zero Ref identifies the unset state.
+type Ref[T comparable] struct {
+	box unsafe.Pointer
+}
+
+// LoadRef returns a [Ref] identifying the current value, along with the value
+// itself (the zero value, if unset).
+func (v *Value[T]) LoadRef() (Ref[T], T) {
+	dp := atomic.LoadPointer(&v.v)
+	return Ref[T]{dp}, boxed[T](dp)
+}
+
+// CompareRefAndSwap stores new if the [Value] still holds the value identified
+// by ref, i.e. if it has not been mutated since ref was loaded, reporting
+// whether it did so. Values are not compared at all, so this works for any
+// dynamic type, and fails after any intervening mutation, even one which
+// stored an equal value.
+func (v *Value[T]) CompareRefAndSwap(ref Ref[T], new T) (swapped bool) {
+	if atomic.LoadPointer(&v.v) != ref.box {
+		return false
+	}
+
+	return v.compareAndSwapBox(ref.box, unsafe.Pointer(&[1]T{new}))
+}
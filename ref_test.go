@@ -0,0 +1,80 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestValue_CompareRefAndSwap(t *testing.T) {
+	var a Value[int]
+	ref, val := a.LoadRef()
+	requireZero(t, val)
+	requireEqual(t, Ref[int]{}, ref)
+
+	requireEqual(t, true, a.CompareRefAndSwap(ref, 1))
+	requireEqual(t, false, a.CompareRefAndSwap(ref, 2)) // stale
+	requireEqual(t, 1, a.Load())
+
+	ref, val = a.LoadRef()
+	requireEqual(t, 1, val)
+
+	// any intervening mutation invalidates the ref, even of an equal value
+	a.Store(1)
+	requireEqual(t, false, a.CompareRefAndSwap(ref, 2))
+	ref, _ = a.LoadRef()
+	a.Swap(1)
+	requireEqual(t, false, a.CompareRefAndSwap(ref, 2))
+	ref, _ = a.LoadRef()
+	requireEqual(t, true, a.CompareAndSwap(1, 1))
+	requireEqual(t, false, a.CompareRefAndSwap(ref, 2))
+
+	// reads and failed mutations don't
+	ref, _ = a.LoadRef()
+	a.Load()
+	requireEqual(t, false, a.CompareAndSwap(5, 6))
+	requireEqual(t, false, a.StoreIfChanged(1))
+	requireEqual(t, true, a.CompareRefAndSwap(ref, 2))
+	requireEqual(t, 2, a.Load())
+
+	// the unset state is a distinct ref from a stored zero value
+	var b Value[int]
+	unset, _ := b.LoadRef()
+	b.Store(0)
+	requireEqual(t, false, b.CompareRefAndSwap(unset, 1))
+	b.Reset()
+	requireEqual(t, true, b.CompareRefAndSwap(unset, 1))
+
+	// values aren't compared, so incomparable dynamic types are fine
+	c := New[any]([]int{1})
+	ref2, _ := c.LoadRef()
+	requireEqual(t, true, c.CompareRefAndSwap(ref2, []int{2}))
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		// an increment loop built on refs loses no updates
+		var av Value[int]
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					for {
+						ref, old := av.LoadRef()
+						if av.CompareRefAndSwap(ref, old+1) {
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, n*iters, av.Load())
+	})
+}
@@ -0,0 +1,164 @@
+package atomicval
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+// interfere arranges for mutate to be called just before each of the next n
+// compare-and-swaps of v, so that they fail and the caller has to retry.
+func interfere[T comparable](t *testing.T, v *Value[T], n int, mutate func(i int)) {
+	t.Helper()
+
+	calls := 0
+	beforeCAS = func(addr *unsafe.Pointer) {
+		if addr != &v.v || calls == n {
+			return
+		}
+
+		calls++
+		mutate(calls)
+	}
+	t.Cleanup(func() {
+		beforeCAS = nil
+		requireEqual(t, n, calls)
+	})
+}
+
+func TestRetries(t *testing.T) {
+	t.Run("Update", func(t *testing.T) {
+		a := New(1)
+		interfere(t, a, 2, func(i int) { a.Store(10 * i) })
+
+		var olds []int
+		a.Update(func(old int) int {
+			olds = append(olds, old)
+			return old + 1
+		})
+
+		// each attempt sees the value stored by the interference before it
+		requireEqual(t, "[1 10 20]", fmt.Sprint(olds))
+		requireEqual(t, 21, a.Load())
+	})
+
+	t.Run("UpdateAndGet", func(t *testing.T) {
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(5) })
+
+		requireEqual(t, 6, a.UpdateAndGet(func(old int) int { return old + 1 }))
+		requireEqual(t, 6, a.Load())
+	})
+
+	t.Run("GetAndUpdate", func(t *testing.T) {
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(5) })
+
+		requireEqual(t, 5, a.GetAndUpdate(func(old int) int { return old + 1 }))
+		requireEqual(t, 6, a.Load())
+	})
+
+	t.Run("Modify", func(t *testing.T) {
+		type pair struct{ x, y int }
+		a := New(pair{1, 1})
+		interfere(t, a, 1, func(int) { a.Store(pair{5, 5}) })
+
+		// the retry starts over from the new value, not the discarded copy
+		var seen []pair
+		a.Modify(func(p *pair) {
+			seen = append(seen, *p)
+			p.x++
+			p.y++
+		})
+		requireEqual(t, "[{1 1} {5 5}]", fmt.Sprint(seen))
+		requireEqual(t, pair{6, 6}, a.Load())
+	})
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		// replaced by an equal value: compared again, and swapped
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(1) })
+		requireEqual(t, true, a.CompareAndSwap(1, 2))
+		requireEqual(t, 2, a.Load())
+	})
+
+	t.Run("CompareAndSwap mismatch", func(t *testing.T) {
+		// replaced by a different value: compared again, and not swapped
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(3) })
+		requireEqual(t, false, a.CompareAndSwap(1, 2))
+		requireEqual(t, 3, a.Load())
+	})
+
+	t.Run("CompareAndSwapPtr", func(t *testing.T) {
+		// *old is compared again after the interference
+		a, old := New(1), 1
+		interfere(t, a, 1, func(int) { a.Store(1) })
+		requireEqual(t, true, a.CompareAndSwapPtr(&old, 2))
+		requireEqual(t, 2, a.Load())
+	})
+
+	t.Run("CompareAndSwapFunc", func(t *testing.T) {
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(1) })
+
+		calls := 0
+		eq := func(cur, old int) bool { calls++; return cur == old }
+		requireEqual(t, true, a.CompareAndSwapFunc(1, 2, eq))
+		requireEqual(t, 2, calls)
+		requireEqual(t, 2, a.Load())
+	})
+
+	t.Run("CompareAndDelete", func(t *testing.T) {
+		// replaced by an equal value: compared again, and deleted
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(1) })
+		requireEqual(t, true, a.CompareAndDelete(1))
+		requireEqual(t, false, a.IsSet())
+	})
+
+	t.Run("CompareAndDelete mismatch", func(t *testing.T) {
+		// replaced by a different value: compared again, and not deleted
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(3) })
+		requireEqual(t, false, a.CompareAndDelete(1))
+		requireEqual(t, 3, a.Load())
+	})
+
+	t.Run("CompareAndSwapWeak", func(t *testing.T) {
+		// replaced by an equal value: fails spuriously
+		a := New(1)
+		interfere(t, a, 1, func(int) { a.Store(1) })
+		requireEqual(t, false, a.CompareAndSwapWeak(1, 2))
+		requireEqual(t, 1, a.Load())
+	})
+
+	t.Run("StoreMax", func(t *testing.T) {
+		var o Ordered[int]
+		o.Store(1)
+		interfere(t, &o.Value, 2, func(i int) { o.Store(i + 1) })
+
+		// the candidate still beats the interfering values
+		requireEqual(t, true, o.StoreMax(5))
+		requireEqual(t, 5, o.Load())
+	})
+
+	t.Run("StoreMax overtaken", func(t *testing.T) {
+		var o Ordered[int]
+		o.Store(1)
+		interfere(t, &o.Value, 1, func(int) { o.Store(9) })
+
+		// the interfering value beats the candidate
+		requireEqual(t, false, o.StoreMax(5))
+		requireEqual(t, 9, o.Load())
+	})
+
+	t.Run("LoadOrStore", func(t *testing.T) {
+		var a Value[int]
+		interfere(t, &a, 1, func(int) { a.Store(7) })
+
+		actual, loaded := a.LoadOrStore(1)
+		requireEqual(t, 7, actual)
+		requireEqual(t, true, loaded)
+	})
+}
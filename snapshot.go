@@ -0,0 +1,38 @@
+package atomicval
+
+import "sync/atomic"
+
+// Snapshot is a point-in-time view of a [Value], as returned by
+// [Value.Snapshot]. All reads through the same Snapshot see the same value,
+// however the Value changes in the meantime, so several fields of a large T
+// can be read consistently without copying all of it.
+//
+// A Snapshot refers to the Value's internal copy of the value, which is never
+// modified once published, so it stays valid (and unchanged) for as long as
+// the Snapshot is kept, at the cost of keeping that copy alive. The zero
+// Snapshot is unset.
+type Snapshot[T comparable] struct {
+	p *T // nil if unset
+}
+
+// Snapshot returns a [Snapshot] of the current value.
+func (v *Value[T]) Snapshot() Snapshot[T] {
+	return Snapshot[T]{(*T)(atomic.LoadPointer(&v.v))}
+}
+
+// Get returns a copy of the value, or the zero value if the Value was unset.
+func (s Snapshot[T]) Get() (val T) {
+	if s.p == nil {
+		return val
+	}
+
+	return *s.p
+}
+
+// Ptr returns a pointer to the value, or nil if the Value was unset, for
+// reading parts of it without copying the whole. As for [Value.LoadPtr], the
+// pointed-to value is shared and must never be modified.
+func (s Snapshot[T]) Ptr() *T { return s.p }
+
+// IsSet reports whether the Value was set.
+func (s Snapshot[T]) IsSet() bool { return s.p != nil }
@@ -0,0 +1,85 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestValue_Snapshot(t *testing.T) {
+	type config struct {
+		Name    string
+		Limits  [64]int
+		Version int
+	}
+
+	var a Value[config]
+	s := a.Snapshot()
+	requireEqual(t, false, s.IsSet())
+	requireEqual(t, (*config)(nil), s.Ptr())
+	requireZero(t, s.Get())
+
+	a.Store(config{Name: "a", Version: 1})
+	s = a.Snapshot()
+	requireEqual(t, true, s.IsSet())
+	requireEqual(t, "a", s.Ptr().Name)
+
+	// a later Store doesn't affect the snapshot
+	a.Store(config{Name: "b", Version: 2})
+	requireEqual(t, "a", s.Ptr().Name)
+	requireEqual(t, 1, s.Get().Version)
+	requireEqual(t, "b", a.Snapshot().Ptr().Name)
+
+	// nor does a Reset
+	a.Reset()
+	requireEqual(t, true, s.IsSet())
+	requireEqual(t, 1, s.Ptr().Version)
+
+	// taking a snapshot doesn't allocate
+	a.Store(config{Name: "c"})
+	requireEqual(t, 0.0, testing.AllocsPerRun(100, func() { _ = a.Snapshot().Ptr().Name }))
+
+	t.Run("concurrent", func(t *testing.T) {
+		iters := 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		// writers store configs whose fields all match; reads of separate
+		// fields through one snapshot must agree, with a Store in between
+		av := New(config{})
+		stored := make(chan struct{})
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		for w := range runtime.GOMAXPROCS(0) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					c := config{Version: w*iters + i}
+					c.Limits[len(c.Limits)-1] = c.Version
+					av.Store(c)
+
+					select {
+					case stored <- struct{}{}:
+					case <-done:
+						return
+					}
+				}
+			}()
+		}
+
+		for range iters {
+			s := av.Snapshot()
+			version := s.Ptr().Version
+			<-stored // at least one Store happens in between
+			if limit := s.Ptr().Limits[63]; limit != version {
+				close(done)
+				wg.Wait()
+				t.Fatalf("inconsistent snapshot: version %d, limit %d", version, limit)
+			}
+		}
+		close(done)
+		wg.Wait()
+	})
+}
@@ -0,0 +1,45 @@
+package atomicval
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+// Value implements [driver.Valuer], converting the current value to a driver
+// value using [driver.DefaultParameterConverter]: T must be one of the types
+// natively supported by drivers (or have one as its underlying type), or
+// implement [driver.Valuer] itself. An unset Value maps to NULL.
+func (v *Value[T]) Value() (driver.Value, error) {
+	val, ok := v.LoadOption().Get()
+	if !ok {
+		return nil, nil
+	}
+
+	dv, err := driver.DefaultParameterConverter.ConvertValue(val)
+	if err != nil {
+		return nil, fmt.Errorf("atomicval: cannot convert Value[%s] to a driver value: %w", reflect.TypeFor[T](), err)
+	}
+
+	return dv, nil
+}
+
+// Scan implements [sql.Scanner], converting src to T with the same rules as
+// [sql.Rows.Scan] and storing the result. Scanning NULL resets the Value to its
+// unset state. If src can't be converted, an error is returned and the Value is
+// unchanged.
+func (v *Value[T]) Scan(src any) error {
+	var n sql.Null[T]
+	if err := n.Scan(src); err != nil {
+		return fmt.Errorf("atomicval: cannot scan into Value[%s]: %w", reflect.TypeFor[T](), err)
+	}
+
+	if !n.Valid {
+		v.Reset()
+		return nil
+	}
+
+	v.Store(n.V)
+	return nil
+}
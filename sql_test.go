@@ -0,0 +1,107 @@
+package atomicval
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+var (
+	_ driver.Valuer = (*Value[int])(nil)
+	_ sql.Scanner   = (*Value[int])(nil)
+)
+
+func TestValue_Value(t *testing.T) {
+	var n Value[int]
+	dv, err := n.Value()
+	requireZero(t, err)
+	requireZero(t, dv)
+
+	n.Store(3)
+	dv, err = n.Value()
+	requireZero(t, err)
+	requireEqual(t, driver.Value(int64(3)), dv)
+
+	type name string
+	var s Value[name]
+	s.Store("a")
+	dv, err = s.Value()
+	requireZero(t, err)
+	requireEqual(t, driver.Value("a"), dv)
+
+	var p Value[*int]
+	p.Store(nil)
+	dv, err = p.Value()
+	requireZero(t, err)
+	requireZero(t, dv)
+
+	var u Value[struct{ X int }]
+	u.Store(struct{ X int }{1})
+	_, err = u.Value()
+	requireNotZero(t, err)
+	requireEqual(t, true, strings.HasPrefix(err.Error(), "atomicval: cannot convert Value[struct { X int }] to a driver value: "))
+}
+
+func TestValue_Scan(t *testing.T) {
+	var n Value[int]
+	requireZero(t, n.Scan(int64(7)))
+	requireEqual(t, 7, n.Load())
+
+	requireZero(t, n.Scan([]byte("8")))
+	requireEqual(t, 8, n.Load())
+
+	requireNotZero(t, n.Scan("eight"))
+	requireEqual(t, 8, n.Load())
+
+	requireZero(t, n.Scan(nil))
+	requireEqual(t, false, n.IsSet())
+
+	var s Value[string]
+	requireZero(t, s.Scan("a"))
+	requireEqual(t, "a", s.Load())
+
+	requireZero(t, s.Scan(int64(1)))
+	requireEqual(t, "1", s.Load())
+
+	// []byte isn't comparable, but string-kinded types take byte columns
+	type blob string
+	var b Value[blob]
+	requireZero(t, b.Scan([]byte{0, 1, 2}))
+	requireEqual(t, blob("\x00\x01\x02"), b.Load())
+
+	dv, err := b.Value()
+	requireZero(t, err)
+	requireEqual(t, driver.Value("\x00\x01\x02"), dv)
+
+	var u Value[struct{ X int }]
+	err = u.Scan(int64(1))
+	requireNotZero(t, err)
+	requireEqual(t, false, u.IsSet())
+}
+
+func TestWrappers_sqlInterfaces(t *testing.T) {
+	// the embedded field named Value hides Value.Value, but not Value.Scan
+	for _, w := range []struct {
+		name string
+		v    any
+	}{
+		{"Bool", new(Bool)},
+		{"Numeric", new(Numeric[int])},
+		{"Ordered", new(Ordered[string])},
+	} {
+		if _, ok := w.v.(sql.Scanner); !ok {
+			t.Errorf("*%s doesn't implement sql.Scanner", w.name)
+		}
+		if _, ok := w.v.(driver.Valuer); ok {
+			t.Errorf("*%s implements driver.Valuer", w.name)
+		}
+	}
+
+	// the documented workaround
+	var n Numeric[int]
+	requireZero(t, n.Scan(int64(4)))
+	dv, err := driver.Valuer(&n.Value).Value()
+	requireZero(t, err)
+	requireEqual(t, driver.Value(int64(4)), dv)
+}
@@ -0,0 +1,65 @@
+package atomicval
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// stamped is the in-box representation of a [StampedValue].
+type stamped[T comparable] struct {
+	val   T
+	stamp uint64
+}
+
+// StampedValue is an atomic store for a value of type T paired with a stamp,
+// which every mutation increments. A CompareAndSwap only succeeds if both the
+// value and the stamp are as expected, so unlike [Value.CompareAndSwap] it
+// fails if the value has changed and changed back since it was loaded (the ABA
+// problem). This holds for as long as the stamp doesn't wrap around, which at
+// one mutation per nanosecond would take over 500 years.
+//
+// An unset StampedValue holds the zero value with stamp 0. The zero
+// StampedValue is ready for use. Must not be copied after first use.
+type StampedValue[T comparable] struct {
+	v Value[stamped[T]]
+}
+
+// Load returns the current value and its stamp.
+func (s *StampedValue[T]) Load() (val T, stamp uint64) {
+	st := s.v.Load()
+	return st.val, st.stamp
+}
+
+// Store sets the value to val, returning the new stamp.
+func (s *StampedValue[T]) Store(val T) (stamp uint64) {
+	box := new([1]stamped[T]) // allocated once, reused across attempts until published
+	for {
+		dp := atomic.LoadPointer(&s.v.v)
+		box[0] = stamped[T]{val, boxed[stamped[T]](dp).stamp + 1}
+		if s.v.compareAndSwapBox(dp, unsafe.Pointer(box)) {
+			return box[0].stamp
+		}
+	}
+}
+
+// CompareAndSwap replaces the current value with new if the value currently
+// equals old (compared as in [Value.CompareAndSwap]) and the stamp is still
+// oldStamp, returning the new stamp and true. Otherwise, it returns the stamp
+// it observed and false.
+func (s *StampedValue[T]) CompareAndSwap(old T, oldStamp uint64, new T) (stamp uint64, swapped bool) {
+	dp := atomic.LoadPointer(&s.v.v)
+	cur := boxed[stamped[T]](dp)
+	if cur.stamp != oldStamp || !equal(&cur.val, &old) {
+		return cur.stamp, false
+	}
+
+	// the stamp only changes along with the box, so a successful swap of the
+	// box ensures that the stamp is still the one compared
+	box := &[1]stamped[T]{{new, oldStamp + 1}}
+	if !s.v.compareAndSwapBox(dp, unsafe.Pointer(box)) {
+		_, stamp = s.Load()
+		return stamp, false
+	}
+
+	return oldStamp + 1, true
+}
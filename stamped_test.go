@@ -0,0 +1,105 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestStampedValue(t *testing.T) {
+	var a StampedValue[string]
+	val, stamp := a.Load()
+	requireZero(t, val)
+	requireEqual(t, uint64(0), stamp)
+
+	requireEqual(t, uint64(1), a.Store("a"))
+	stamp, ok := a.CompareAndSwap("a", 0, "b") // stale stamp
+	requireEqual(t, false, ok)
+	requireEqual(t, uint64(1), stamp)
+	stamp, ok = a.CompareAndSwap("x", 1, "b") // wrong value
+	requireEqual(t, false, ok)
+	requireEqual(t, uint64(1), stamp)
+	stamp, ok = a.CompareAndSwap("a", 1, "b")
+	requireEqual(t, true, ok)
+	requireEqual(t, uint64(2), stamp)
+
+	val, stamp = a.Load()
+	requireEqual(t, "b", val)
+	requireEqual(t, uint64(2), stamp)
+
+	// unset compares as the zero value with stamp 0
+	var b StampedValue[int]
+	stamp, ok = b.CompareAndSwap(0, 0, 1)
+	requireEqual(t, true, ok)
+	requireEqual(t, uint64(1), stamp)
+
+	t.Run("ABA", func(t *testing.T) {
+		type node struct{ next *node }
+		x, y := &node{}, &node{}
+
+		// a reader loads x, then other goroutines replace it and put it back
+		var plain Value[*node]
+		plain.Store(x)
+		seen := plain.Load()
+		plain.Store(y)
+		plain.Store(x)
+
+		// the plain CompareAndSwap can't tell
+		requireEqual(t, true, plain.CompareAndSwap(seen, nil))
+
+		var sv StampedValue[*node]
+		sv.Store(x)
+		seen, seenStamp := sv.Load()
+		sv.Store(y)
+		sv.Store(x)
+
+		// the stamped one can
+		stamp, ok := sv.CompareAndSwap(seen, seenStamp, nil)
+		requireEqual(t, false, ok)
+		requireEqual(t, seenStamp+2, stamp)
+		cur, _ := sv.Load()
+		requireEqual(t, x, cur)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		// the same two values alternate, so only the stamp distinguishes
+		// states: every successful swap must be the only one from its stamp
+		var sv StampedValue[bool]
+		var wg sync.WaitGroup
+		wins := make([][]uint64, n)
+		for g := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					for {
+						val, stamp := sv.Load()
+						if _, ok := sv.CompareAndSwap(val, stamp, !val); ok {
+							wins[g] = append(wins[g], stamp)
+							break
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		from := make(map[uint64]bool)
+		for _, ws := range wins {
+			for _, stamp := range ws {
+				if from[stamp] {
+					t.Fatalf("two swaps from stamp %d", stamp)
+				}
+				from[stamp] = true
+			}
+		}
+		val, stamp := sv.Load()
+		requireEqual(t, uint64(n*iters), stamp)
+		requireEqual(t, false, val) // an even number of flips
+	})
+}
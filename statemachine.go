@@ -0,0 +1,61 @@
+package atomicval
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIllegalTransition is returned (wrapped) by [StateMachine.Transition] when
+// the requested state is not an allowed successor of the current one.
+var ErrIllegalTransition = errors.New("atomicval: illegal state transition")
+
+// StateMachine is an atomic store for a state of type S which only permits the
+// transitions given at construction. Concurrent transitions are resolved with
+// compare-and-swap, so of several goroutines racing to move out of the same
+// state, exactly one succeeds and the rest are judged against the new state.
+//
+// Must not be copied after first use.
+type StateMachine[S comparable] struct {
+	state   Value[S]
+	allowed map[[2]S]struct{} // {from, to}
+}
+
+// NewStateMachine returns a [StateMachine] in the initial state, where
+// transitions maps each state to its allowed successors. States with no entry
+// are terminal. transitions is copied, so later changes to it have no effect.
+func NewStateMachine[S comparable](initial S, transitions map[S][]S) *StateMachine[S] {
+	m := &StateMachine[S]{allowed: make(map[[2]S]struct{})}
+	for from, tos := range transitions {
+		for _, to := range tos {
+			m.allowed[[2]S{from, to}] = struct{}{}
+		}
+	}
+
+	m.state.Store(initial)
+	return m
+}
+
+// State returns the current state.
+func (m *StateMachine[S]) State() S { return m.state.Load() }
+
+// CanTransition reports whether to is an allowed successor of from.
+func (m *StateMachine[S]) CanTransition(from, to S) bool {
+	_, ok := m.allowed[[2]S{from, to}]
+	return ok
+}
+
+// Transition moves to the state to if it is an allowed successor of the
+// current state. Otherwise, it returns an error wrapping
+// [ErrIllegalTransition] and leaves the state unchanged.
+func (m *StateMachine[S]) Transition(to S) error {
+	for {
+		from := m.state.Load()
+		if !m.CanTransition(from, to) {
+			return fmt.Errorf("%w: %v -> %v", ErrIllegalTransition, from, to)
+		}
+
+		if m.state.CompareAndSwap(from, to) {
+			return nil
+		}
+	}
+}
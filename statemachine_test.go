@@ -0,0 +1,95 @@
+package atomicval
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type lifecycle string
+
+const (
+	created lifecycle = "created"
+	ready   lifecycle = "ready"
+	closed  lifecycle = "closed"
+)
+
+var lifecycleTransitions = map[lifecycle][]lifecycle{
+	created: {ready, closed},
+	ready:   {closed},
+}
+
+func TestStateMachine(t *testing.T) {
+	m := NewStateMachine(created, lifecycleTransitions)
+	requireEqual(t, created, m.State())
+	requireEqual(t, true, m.CanTransition(created, ready))
+	requireEqual(t, false, m.CanTransition(ready, created))
+
+	requireEqual(t, nil, m.Transition(ready))
+	requireEqual(t, ready, m.State())
+
+	err := m.Transition(created)
+	requireEqual(t, true, errors.Is(err, ErrIllegalTransition))
+	requireEqual(t, "atomicval: illegal state transition: ready -> created", err.Error())
+	requireEqual(t, true, errors.Is(m.Transition(ready), ErrIllegalTransition))
+	requireEqual(t, ready, m.State())
+
+	requireEqual(t, nil, m.Transition(closed))
+	requireEqual(t, closed, m.State())
+
+	// terminal
+	for _, to := range []lifecycle{created, ready, closed} {
+		requireEqual(t, true, errors.Is(m.Transition(to), ErrIllegalTransition))
+	}
+
+	t.Run("table is copied", func(t *testing.T) {
+		tbl := map[lifecycle][]lifecycle{created: {ready}}
+		m := NewStateMachine(created, tbl)
+		tbl[created] = []lifecycle{closed}
+		requireEqual(t, true, errors.Is(m.Transition(closed), ErrIllegalTransition))
+		requireEqual(t, nil, m.Transition(ready))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// created -> ready and created -> closed compete, and ready -> closed
+		// is also legal, so every race must end in one of a few outcomes with
+		// exactly one winner out of created
+		rounds := 1000
+		if testing.Short() {
+			rounds = 100
+		}
+
+		for range rounds {
+			m := NewStateMachine(created, lifecycleTransitions)
+
+			var wg sync.WaitGroup
+			var readyWins, closedWins atomic.Int32
+			for i := range 8 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					to, wins := ready, &readyWins
+					if i%2 == 0 {
+						to, wins = closed, &closedWins
+					}
+
+					switch err := m.Transition(to); {
+					case err == nil:
+						wins.Add(1)
+					case !errors.Is(err, ErrIllegalTransition):
+						t.Errorf("unexpected error: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			// closed can be reached directly or via ready, but only once
+			requireEqual(t, closed, m.State())
+			requireEqual(t, int32(1), closedWins.Load())
+			if n := readyWins.Load(); n > 1 {
+				t.Fatalf("ready reached %d times", n)
+			}
+		}
+	})
+}
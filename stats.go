@@ -0,0 +1,25 @@
+package atomicval
+
+// Stats holds counts of operations performed on a [Value], see [Value.Stats].
+type Stats struct {
+	Loads                 uint64 // calls to Load
+	Stores                uint64 // calls to Store
+	Swaps                 uint64 // calls to Swap
+	CompareAndSwaps       uint64 // calls to CompareAndSwap which swapped
+	FailedCompareAndSwaps uint64 // calls to CompareAndSwap which did not swap
+}
+
+// Stats returns the number of Load, Store, Swap and CompareAndSwap calls made
+// on the [Value] so far, for profiling. Other methods are counted only where
+// they happen to call one of these, so don't rely on their effect on the counts.
+//
+// Counting must be enabled by building with the atomicval_stats tag, since it
+// adds an atomic increment to every counted operation (and five counters to
+// every Value). Otherwise, the counting compiles away entirely and Stats
+// always returns zero counts.
+//
+// The counters are updated independently, so Stats taken during concurrent
+// operations is not a consistent snapshot across fields.
+func (v *Value[T]) Stats() Stats {
+	return v.stats.snapshot()
+}
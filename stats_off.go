@@ -0,0 +1,13 @@
+//go:build !atomicval_stats
+
+package atomicval
+
+// opStats is empty without the atomicval_stats tag, and its methods are no-ops
+// which the compiler removes.
+type opStats struct{}
+
+func (*opStats) load()               {}
+func (*opStats) store()              {}
+func (*opStats) swap()               {}
+func (*opStats) compareAndSwap(bool) {}
+func (*opStats) snapshot() Stats     { return Stats{} }
@@ -0,0 +1,5 @@
+//go:build !atomicval_stats
+
+package atomicval
+
+const statsEnabled = false
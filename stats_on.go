@@ -0,0 +1,32 @@
+//go:build atomicval_stats
+
+package atomicval
+
+import "sync/atomic"
+
+// opStats counts operations on a Value.
+type opStats struct {
+	loads, stores, swaps, casOK, casFailed atomic.Uint64
+}
+
+func (s *opStats) load()  { s.loads.Add(1) }
+func (s *opStats) store() { s.stores.Add(1) }
+func (s *opStats) swap()  { s.swaps.Add(1) }
+
+func (s *opStats) compareAndSwap(swapped bool) {
+	if swapped {
+		s.casOK.Add(1)
+	} else {
+		s.casFailed.Add(1)
+	}
+}
+
+func (s *opStats) snapshot() Stats {
+	return Stats{
+		Loads:                 s.loads.Load(),
+		Stores:                s.stores.Load(),
+		Swaps:                 s.swaps.Load(),
+		CompareAndSwaps:       s.casOK.Load(),
+		FailedCompareAndSwaps: s.casFailed.Load(),
+	}
+}
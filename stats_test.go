@@ -0,0 +1,119 @@
+package atomicval
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValue_Stats(t *testing.T) {
+	var a Value[int]
+	a.Load()
+	a.Store(1)
+	a.Load()
+	a.Swap(2)
+	a.CompareAndSwap(2, 3)
+	a.CompareAndSwap(2, 4)
+	a.CompareAndSwap(2, 5)
+	a.Update(func(old int) int { return old + 1 }) // not counted
+
+	want := Stats{Loads: 2, Stores: 1, Swaps: 1, CompareAndSwaps: 1, FailedCompareAndSwaps: 2}
+	if !statsEnabled {
+		want = Stats{}
+	}
+	requireEqual(t, want, a.Stats())
+
+	t.Run("internal reads not counted", func(t *testing.T) {
+		// delivering to subscribers and observers reads the value, but not
+		// through Load
+		var av Value[int]
+		ch, cancel := av.Subscribe()
+		defer cancel()
+		for val := range av.Observe(context.Background()) {
+			if val == 1 {
+				break
+			}
+			av.Store(1)
+			requireEqual(t, 1, <-ch)
+		}
+		_, err := av.Wait(context.Background(), func(val int) bool { return val == 1 })
+		requireZero(t, err)
+
+		want := Stats{Stores: 1}
+		if !statsEnabled {
+			want = Stats{}
+		}
+		requireEqual(t, want, av.Stats())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		var av Value[int]
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					av.Store(av.Load() + 1)
+					av.Swap(0)
+					av.CompareAndSwap(0, 0)
+				}
+			}()
+		}
+		wg.Wait()
+
+		got := av.Stats()
+		if !statsEnabled {
+			requireEqual(t, Stats{}, got)
+			return
+		}
+
+		ops := uint64(n * iters)
+		requireEqual(t, ops, got.Loads)
+		requireEqual(t, ops, got.Stores)
+		requireEqual(t, ops, got.Swaps)
+		requireEqual(t, ops, got.CompareAndSwaps+got.FailedCompareAndSwaps)
+	})
+}
+
+// BenchmarkStats compares counted operations against the same operations done
+// directly on the underlying pointer. Without the atomicval_stats tag the two
+// should be indistinguishable.
+func BenchmarkStats(b *testing.B) {
+	b.Logf("stats enabled: %v", statsEnabled)
+
+	b.Run("Load", func(b *testing.B) {
+		av := New(1)
+		for range b.N {
+			runtime.KeepAlive(av.Load())
+		}
+	})
+
+	b.Run("Load_uncounted", func(b *testing.B) {
+		av := New(1)
+		for range b.N {
+			runtime.KeepAlive(*(*int)(atomic.LoadPointer(&av.v)))
+		}
+	})
+
+	b.Run("CompareAndSwap", func(b *testing.B) {
+		av := New(1)
+		for range b.N {
+			av.CompareAndSwap(1, 1)
+		}
+	})
+
+	b.Run("CompareAndSwap_uncounted", func(b *testing.B) {
+		av, old := New(1), 1
+		for range b.N {
+			av.compareAndSwap(&old, 1)
+		}
+	})
+}
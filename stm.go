@@ -0,0 +1,209 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Committing transactions are serialized by stmMu, and stmSeq is odd while a
+// commit is being applied. Together they form a global seqlock: a transaction
+// whose reads all happened while stmSeq held the same even value observed a
+// consistent snapshot.
+//
+// Since every mutation of a Value publishes a freshly allocated box, and a box
+// can't be reused while a transaction still references it, the box pointer
+// serves as the Value's version for validation.
+var (
+	stmMu  sync.Mutex
+	stmSeq atomic.Uint64
+)
+
+// Tx is an in-progress transaction, see [Atomically].
+type Tx struct {
+	rv     uint64                             // stmSeq value at which reads were last validated
+	reads  map[*unsafe.Pointer]unsafe.Pointer // Value's box field -> box observed
+	writes map[*unsafe.Pointer]txWrite        // Value's box field -> pending write
+
+	doomed bool // a conflict was detected, the attempt must not commit
+}
+
+// txWrite is a box to be published by a commit, and the notification of the
+// Value it belongs to.
+type txWrite struct {
+	box     unsafe.Pointer
+	old     unsafe.Pointer // box replaced by the commit
+	changed func(old, new unsafe.Pointer)
+}
+
+// stmConflict is panicked to abandon an attempt once its reads are known to be
+// inconsistent, and recovered by [Atomically].
+type stmConflict struct{}
+
+// Atomically runs fn as a transaction over any number of Values, accessed via
+// [TxGet] and [TxSet]. The transaction's writes are published together, and
+// only if none of the Values it read have changed in the meantime; otherwise
+// fn is run again. Within fn, reads always observe a consistent snapshot:
+// an attempt which would observe a torn state is abandoned and retried instead.
+// If fn returns an error, the transaction is abandoned without writing anything
+// and the error is returned.
+//
+// Since fn may run several times, it should have no side effects other than
+// through the Tx, and it must not call Atomically itself.
+//
+// Transactions are only atomic with respect to each other. Values accessed
+// transactionally must only be modified through transactions, and a plain
+// [Value.Load] may observe a commit which is only partially applied.
+func Atomically(fn func(tx *Tx) error) error {
+	for {
+		committed, err := attempt(fn)
+		if err != nil || committed {
+			return err
+		}
+
+		runtime.Gosched()
+	}
+}
+
+// attempt runs fn once, committing if possible.
+func attempt(fn func(tx *Tx) error) (committed bool, err error) {
+	tx := &Tx{
+		rv:     stableSeq(),
+		reads:  make(map[*unsafe.Pointer]unsafe.Pointer),
+		writes: make(map[*unsafe.Pointer]txWrite),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(stmConflict); !ok {
+				panic(r)
+			}
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return false, err
+	}
+
+	return tx.commit(), nil
+}
+
+// stableSeq waits for any in-progress commit and returns the (even) stmSeq.
+func stableSeq() uint64 {
+	for {
+		if seq := stmSeq.Load(); seq&1 == 0 {
+			return seq
+		}
+
+		runtime.Gosched()
+	}
+}
+
+// TxGet returns the value of v as seen by tx: the last value set by [TxSet] in
+// this transaction, or else the one consistent with all of tx's other reads.
+// Like [Value.Load], it returns the zero value if v is unset.
+func TxGet[T comparable](tx *Tx, v *Value[T]) (val T) {
+	box := tx.load(&v.v)
+	if box == nil {
+		return val
+	}
+
+	return *(*T)(box)
+}
+
+// TxSet sets v to val as part of tx. The write is only visible to other
+// goroutines once the transaction commits.
+func TxSet[T comparable](tx *Tx, v *Value[T], val T) {
+	tx.writes[&v.v] = txWrite{box: unsafe.Pointer(&[1]T{val}), changed: v.changed}
+}
+
+func (tx *Tx) load(key *unsafe.Pointer) unsafe.Pointer {
+	if w, ok := tx.writes[key]; ok {
+		return w.box
+	}
+
+	if box, ok := tx.reads[key]; ok {
+		return box
+	}
+
+	for {
+		box := atomic.LoadPointer(key)
+		if stmSeq.Load() == tx.rv {
+			// no commit has started since the other reads were validated
+			tx.reads[key] = box
+			return box
+		}
+
+		tx.extend()
+	}
+}
+
+// extend checks that tx's reads are still current, so that its snapshot can be
+// advanced past commits which happened since. If not, the attempt is abandoned.
+func (tx *Tx) extend() {
+	for {
+		seq := stableSeq()
+		if !tx.readsCurrent() {
+			tx.doomed = true
+			panic(stmConflict{})
+		}
+
+		if stmSeq.Load() == seq {
+			tx.rv = seq
+			return
+		}
+	}
+}
+
+func (tx *Tx) readsCurrent() bool {
+	for key, box := range tx.reads {
+		if atomic.LoadPointer(key) != box {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (tx *Tx) commit() bool {
+	if tx.doomed {
+		return false
+	}
+
+	if len(tx.writes) == 0 {
+		// reads were consistent as of tx.rv, nothing else to do
+		return true
+	}
+
+	if !tx.publish() {
+		return false
+	}
+
+	// observers are notified once the whole commit is visible
+	for _, w := range tx.writes {
+		w.changed(w.old, w.box)
+	}
+
+	return true
+}
+
+// publish applies tx's writes if its reads are still current.
+func (tx *Tx) publish() bool {
+	stmMu.Lock()
+	defer stmMu.Unlock()
+
+	stmSeq.Add(1)
+	defer stmSeq.Add(1)
+
+	if !tx.readsCurrent() {
+		return false
+	}
+
+	for key, w := range tx.writes {
+		w.old = atomic.SwapPointer(key, w.box)
+		tx.writes[key] = w
+	}
+
+	return true
+}
@@ -0,0 +1,161 @@
+package atomicval
+
+import (
+	"errors"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestAtomically(t *testing.T) {
+	var a, b Value[int]
+	a.Store(10)
+
+	err := Atomically(func(tx *Tx) error {
+		x := TxGet(tx, &a)
+		TxSet(tx, &a, x-3)
+		TxSet(tx, &b, TxGet(tx, &b)+3)
+
+		// reads observe the transaction's own writes
+		requireEqual(t, 7, TxGet(tx, &a))
+		requireEqual(t, 3, TxGet(tx, &b))
+		return nil
+	})
+	requireEqual(t, nil, err)
+	requireEqual(t, 7, a.Load())
+	requireEqual(t, 3, b.Load())
+
+	t.Run("error abandons", func(t *testing.T) {
+		errInsufficient := errors.New("insufficient funds")
+		err := Atomically(func(tx *Tx) error {
+			TxSet(tx, &b, TxGet(tx, &b)+100)
+			if TxGet(tx, &a) < 100 {
+				return errInsufficient
+			}
+			TxSet(tx, &a, TxGet(tx, &a)-100)
+			return nil
+		})
+		requireEqual(t, errInsufficient, err)
+		requireEqual(t, 7, a.Load())
+		requireEqual(t, 3, b.Load())
+	})
+
+	t.Run("other panics propagate", func(t *testing.T) {
+		defer func() {
+			requireEqual[any](t, "boom", recover())
+		}()
+		_ = Atomically(func(tx *Tx) error { panic("boom") })
+	})
+
+	t.Run("concurrent transfers", func(t *testing.T) {
+		// move random amounts between accounts; the total must always be
+		// constant, both as seen by concurrent transactions and at the end
+		const accounts, total = 4, 1000
+		iters := 5000
+		if testing.Short() {
+			iters = 500
+		}
+
+		var accts [accounts]Value[int]
+		accts[0].Store(total)
+
+		var wg sync.WaitGroup
+		failChan := make(chan int, 1)
+		done := make(chan struct{})
+
+		// read-only observers
+		var observers sync.WaitGroup
+		for range 2 {
+			observers.Add(1)
+			go func() {
+				defer observers.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					var sum int
+					_ = Atomically(func(tx *Tx) error {
+						sum = 0
+						for i := range accts {
+							sum += TxGet(tx, &accts[i])
+						}
+						return nil
+					})
+					if sum != total {
+						select {
+						case failChan <- sum:
+						default:
+						}
+						return
+					}
+				}
+			}()
+		}
+
+		for range 4 * runtime.GOMAXPROCS(0) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					from, to := rand.IntN(accounts), rand.IntN(accounts)
+					_ = Atomically(func(tx *Tx) error {
+						bal := TxGet(tx, &accts[from])
+						if bal == 0 {
+							return nil
+						}
+						amt := 1 + rand.IntN(bal)
+						TxSet(tx, &accts[from], bal-amt)
+						TxSet(tx, &accts[to], TxGet(tx, &accts[to])+amt)
+						return nil
+					})
+				}
+			}()
+		}
+
+		wg.Wait()
+		close(done)
+		observers.Wait()
+		close(failChan)
+		for sum := range failChan {
+			t.Fatalf("observed total %d, expected %d", sum, total)
+		}
+
+		var sum int
+		for i := range accts {
+			sum += accts[i].Load()
+		}
+		requireEqual(t, total, sum)
+	})
+
+	t.Run("no lost updates", func(t *testing.T) {
+		var counter, other Value[int]
+		n := 4 * runtime.GOMAXPROCS(0)
+		iters := 2000
+		if testing.Short() {
+			iters = 200
+		}
+
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					_ = Atomically(func(tx *Tx) error {
+						TxSet(tx, &counter, TxGet(tx, &counter)+1)
+						TxSet(tx, &other, TxGet(tx, &other)-1)
+						return nil
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, n*iters, counter.Load())
+		requireEqual(t, -n*iters, other.Load())
+	})
+}
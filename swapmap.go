@@ -0,0 +1,87 @@
+package atomicval
+
+import "sync"
+
+// SwappableMap is a concurrent map, backed by [sync.Map], which can be cleared
+// atomically via [SwappableMap.Reset]. Rather than deleting entries in place,
+// Reset installs a fresh inner map, so every operation (including a whole
+// [SwappableMap.Range]) sees either the old contents or the new, empty map,
+// never a partially cleared one.
+//
+// An operation which races with Reset may apply to the map being replaced; in
+// particular a Store concurrent with Reset may be discarded along with the old
+// contents.
+//
+// The zero SwappableMap is empty and ready for use. Must not be copied after
+// first use.
+type SwappableMap[K comparable, V any] struct {
+	m Value[*sync.Map]
+}
+
+// current returns the inner map, initializing it if needed.
+func (m *SwappableMap[K, V]) current() *sync.Map {
+	for {
+		if cur := m.m.Load(); cur != nil {
+			return cur
+		}
+
+		m.m.CompareAndSwap(nil, new(sync.Map))
+	}
+}
+
+// Load returns the value stored for key, if any.
+func (m *SwappableMap[K, V]) Load(key K) (value V, ok bool) {
+	cur := m.m.Load()
+	if cur == nil {
+		return value, false
+	}
+
+	v, ok := cur.Load(key)
+	if !ok {
+		return value, false
+	}
+
+	value, _ = v.(V) // nil for a stored nil interface
+	return value, true
+}
+
+// Store sets the value for key.
+func (m *SwappableMap[K, V]) Store(key K, value V) {
+	m.current().Store(key, value)
+}
+
+// LoadOrStore returns the existing value for key if present. Otherwise, it
+// stores and returns value. loaded reports whether the value was loaded.
+func (m *SwappableMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	v, loaded := m.current().LoadOrStore(key, value)
+	actual, _ = v.(V)
+	return actual, loaded
+}
+
+// Delete removes the value for key.
+func (m *SwappableMap[K, V]) Delete(key K) {
+	if cur := m.m.Load(); cur != nil {
+		cur.Delete(key)
+	}
+}
+
+// Range calls f sequentially for each key and value in the map, stopping if f
+// returns false. All calls observe the same inner map, so a concurrent Reset
+// does not affect an ongoing Range; otherwise, the semantics of
+// [sync.Map.Range] apply.
+func (m *SwappableMap[K, V]) Range(f func(key K, value V) bool) {
+	cur := m.m.Load()
+	if cur == nil {
+		return
+	}
+
+	cur.Range(func(k, v any) bool {
+		value, _ := v.(V)
+		return f(k.(K), value)
+	})
+}
+
+// Reset atomically replaces the contents with an empty map.
+func (m *SwappableMap[K, V]) Reset() {
+	m.m.Store(new(sync.Map))
+}
@@ -0,0 +1,111 @@
+package atomicval
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestSwappableMap(t *testing.T) {
+	var m SwappableMap[string, int]
+
+	_, ok := m.Load("a")
+	requireEqual(t, false, ok)
+	m.Delete("a")
+	m.Range(func(string, int) bool { t.Fatal("unexpected entry"); return false })
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	got, ok := m.Load("a")
+	requireEqual(t, true, ok)
+	requireEqual(t, 1, got)
+
+	actual, loaded := m.LoadOrStore("b", 3)
+	requireEqual(t, true, loaded)
+	requireEqual(t, 2, actual)
+	actual, loaded = m.LoadOrStore("c", 3)
+	requireEqual(t, false, loaded)
+	requireEqual(t, 3, actual)
+
+	m.Delete("c")
+	sum := 0
+	m.Range(func(_ string, v int) bool { sum += v; return true })
+	requireEqual(t, 3, sum)
+
+	m.Reset()
+	_, ok = m.Load("a")
+	requireEqual(t, false, ok)
+	m.Range(func(string, int) bool { t.Fatal("unexpected entry"); return false })
+
+	m.Store("a", 4)
+	got, _ = m.Load("a")
+	requireEqual(t, 4, got)
+
+	t.Run("nil interface values", func(t *testing.T) {
+		var m SwappableMap[int, io.Writer]
+		m.Store(1, nil)
+		w, ok := m.Load(1)
+		requireEqual(t, true, ok)
+		requireZero(t, w)
+
+		w, loaded := m.LoadOrStore(1, io.Discard)
+		requireEqual(t, true, loaded)
+		requireZero(t, w)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// each inner map only ever holds entries from a single generation, so
+		// seeing mixed generations in one Range would mean a torn Reset
+		const keys = 32
+		gens := 2000
+		if testing.Short() {
+			gens = 200
+		}
+
+		var m SwappableMap[int, int]
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		failChan := make(chan error, 8)
+
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					gen := -1
+					m.Range(func(k, v int) bool {
+						if gen == -1 {
+							gen = v
+						}
+						if v != gen {
+							failChan <- fmt.Errorf("key %d: generation %d mixed with %d", k, v, gen)
+							return false
+						}
+						return true
+					})
+				}
+			}()
+		}
+
+		for gen := range gens {
+			m.Reset()
+			for k := range keys {
+				m.Store(k, gen)
+			}
+		}
+
+		close(done)
+		wg.Wait()
+		close(failChan)
+		for err := range failChan {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+// Package copylocks is checked by TestValue_vetCopylocks: go vet must report
+// each line marked with a "want" comment, and nothing else.
+package copylocks
+
+import "github.com/rhallora-heidelberg/atomicval"
+
+type config struct {
+	name string
+	mode atomicval.Value[string]
+}
+
+func byValue(v atomicval.Value[int]) int { // want
+	return v.Load()
+}
+
+func byPointer(v *atomicval.Value[int]) int {
+	return v.Load()
+}
+
+func copies() {
+	var a atomicval.Value[int]
+	a.Store(1)
+
+	b := a         // want
+	_ = byValue(b) // want
+	_ = byPointer(&a)
+
+	var c config
+	d := c // want
+	_ = d.name
+
+	e := []atomicval.Value[int]{{}}
+	for _, f := range e { // want
+		_ = f.Load()
+	}
+
+	var g atomicval.FairValue[int]
+	h := g // want
+	_ = h.Load()
+
+	var i atomicval.Pointer[int]
+	j := i // want
+	_ = j.Load()
+
+	// not reported: vet doesn't follow copies through a dereference
+	k := *atomicval.New(2)
+	_ = k.Load()
+}
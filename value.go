@@ -1,38 +1,99 @@
 // Package atomicval provides [Value]: an atomic value store which is a safer,
 // friendlier, and often faster alternative to [atomic.Value]. Relative to
 // the standard library, it:
-//   - will not raise panics
+//   - will not raise panics, except [Value.MustLoad] on an unset [Value], which
+//     exists to do so
 //   - is safe for any type T allowed by the constraint ([unsafe.Pointer] shenanigans
 //     aside, perhaps)
-//   - does not prohibit/panic on mixed concrete types for the same interface type
+//   - does not prohibit/panic on mixed concrete types for the same interface type,
+//     even where they are not comparable (such values are simply unequal)
 //   - properly handles nils as a zero-value for applicable types (e.g.
 //     `Store(nil)`, or [Value.CompareAndSwap] on an uninitialized [Value].)
 package atomicval
 
 import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"runtime"
 	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
+// timeNow is the clock used by time-dependent methods; replaced in tests.
+var timeNow = time.Now
+
+// beforeCAS, if set, is called by compareAndSwapBox with the word about to be
+// swapped, just before swapping it; set in tests to force retries.
+var beforeCAS func(addr *unsafe.Pointer)
+
 // Value provides atomic operations for values of a given type. It is based
 // on [atomic.Value], but is designed to be safer and more user-friendly in
 // that it will not panic, treats an uninitialized state as equivalent to
 // a zero-value of the given type, and allows interfaces of mixed underlying
 // types.
 //
-// Must not be copied after first use.
+// In the terminology of the Go memory model, each mutation (Store, Swap, a
+// successful CompareAndSwap and so on) is synchronized before any Load which
+// observes its result. Everything written before storing a value, including
+// memory it points to, is therefore visible to a goroutine which loads it.
+//
+// Must not be copied after first use; go vet's copylocks check reports most
+// copies, though not those made by dereferencing a pointer. Copying reads the
+// current value non-atomically, which races with concurrent mutations. A copy
+// made anyway starts out holding the same value, but is otherwise independent:
+// stored values are immutable and shared safely, mutations of either are not
+// seen by the other, and observers registered by [Value.Subscribe] or
+// [Value.SetOnChange] stay with the original.
 type Value[T comparable] struct {
 	_ noCopy
 
 	// prevent unruly type conversions (see [atomic.Pointer])
 	_ [0]*T
 
+	// operation counts; zero-size (placed first so as not to add trailing
+	// padding) unless built with the atomicval_stats tag, see [Value.Stats]
+	stats opStats
+
 	v unsafe.Pointer
+
+	hooks atomic.Pointer[hooks[T]] // change notification, nil if unobserved
+}
+
+// The fields before v must not push it off pointer alignment, which
+// atomic operations require on 32-bit platforms. Pointer fields are always
+// naturally aligned, so this holds for any T; fail to compile if that changes.
+var _ [0]struct{} = [unsafe.Offsetof(Value[byte]{}.v) % unsafe.Alignof(unsafe.Pointer(nil))]struct{}{}
+
+// New returns a [Value] holding initial.
+func New[T comparable](initial T) *Value[T] {
+	return &Value[T]{v: unsafe.Pointer(&[1]T{initial})}
+}
+
+// NewUnset returns an unset [Value], equivalent to new(Value[T]).
+func NewUnset[T comparable]() *Value[T] {
+	return new(Value[T])
+}
+
+// ValueOf returns a [Value] holding initial, with T inferred from it. It is
+// equivalent to [New], but reads better in generic code, where a Value is
+// built around a value whose type is only known as a type parameter.
+func ValueOf[T comparable](initial T) *Value[T] {
+	return New(initial)
+}
+
+// ZeroValue returns an unset [Value], equivalent to [NewUnset]. It pairs with
+// [ValueOf] for generic code which needs a Value before it has a value.
+func ZeroValue[T comparable]() *Value[T] {
+	return NewUnset[T]()
 }
 
 // Load returns the value set by the most recent Store. Returns the zero value
 // if no value has been set.
 func (v *Value[T]) Load() (val T) {
+	v.stats.load()
 	dp := atomic.LoadPointer(&v.v)
 	if dp == nil {
 		return val
@@ -41,15 +102,112 @@ func (v *Value[T]) Load() (val T) {
 	return (*[1]T)(dp)[0]
 }
 
+// LoadPtr returns a pointer to the current value, or nil if no value has been
+// set. This avoids copying the value, which may be worthwhile for large T.
+//
+// The pointer refers to the Value's internal copy, which is shared with other
+// callers and must never be modified. Later mutations of the Value publish a
+// new copy rather than changing this one, so the pointed-to value remains
+// valid (and unchanged) indefinitely.
+func (v *Value[T]) LoadPtr() *T {
+	return (*T)(atomic.LoadPointer(&v.v))
+}
+
+// IsSet reports whether a value has been set, i.e. whether any Store, Swap or
+// successful CompareAndSwap has occurred (since the last Reset, if any). This
+// distinguishes an unset [Value] from one explicitly holding the zero value.
+func (v *Value[T]) IsSet() bool {
+	return atomic.LoadPointer(&v.v) != nil
+}
+
+// MustLoad is like [Value.Load], but panics if no value has been set, rather
+// than returning the zero value. This is for Values which must be initialized
+// before use, turning a missed initialization into a loud failure.
+func (v *Value[T]) MustLoad() T {
+	dp := atomic.LoadPointer(&v.v)
+	if dp == nil {
+		panic(fmt.Sprintf("atomicval: MustLoad of unset Value[%s]", reflect.TypeFor[T]()))
+	}
+
+	return *(*T)(dp)
+}
+
 // Store sets the value of the [Value] v to val.
 func (v *Value[T]) Store(val T) {
-	atomic.StorePointer(&v.v, unsafe.Pointer(&[1]T{val}))
+	v.stats.store()
+	box := unsafe.Pointer(&[1]T{val})
+	v.changed(atomic.SwapPointer(&v.v, box), box)
+}
+
+// StorePtr sets the value to *p without copying it, adopting p as the Value's
+// internal copy, or resets the Value to unset if p is nil. The caller must not
+// modify *p afterwards, since it may be observed by any subsequent Load (and
+// is returned as is by [Value.LoadPtr]).
+func (v *Value[T]) StorePtr(p *T) {
+	old := atomic.SwapPointer(&v.v, unsafe.Pointer(p))
+	if old == nil && p == nil {
+		return // unset to unset isn't a change
+	}
+
+	v.changed(old, unsafe.Pointer(p))
+}
+
+// Reset returns the [Value] to its initial, unset state: subsequent Loads
+// return the zero value and IsSet reports false until a new value is set.
+func (v *Value[T]) Reset() {
+	if old := atomic.SwapPointer(&v.v, nil); old != nil {
+		v.changed(old, nil)
+	}
+}
+
+// Take atomically loads the current value and resets the [Value] to unset,
+// reporting whether a value was present. Of several goroutines racing to Take
+// the same stored value, exactly one gets ok == true.
+func (v *Value[T]) Take() (val T, ok bool) {
+	dp := atomic.SwapPointer(&v.v, nil)
+	if dp == nil {
+		return val, false
+	}
+
+	v.changed(dp, nil)
+	return *(*T)(dp), true
+}
+
+// LoadAndReset atomically loads the current value and resets the [Value] to
+// unset, returning the zero value if it was already unset. This suits draining
+// an accumulated value for reporting, where the next window should start from
+// scratch. It is [Value.Take] without the presence flag: afterwards, the Value
+// reads as unset rather than as a stored zero value.
+func (v *Value[T]) LoadAndReset() T {
+	val, _ := v.Take()
+	return val
+}
+
+// DrainSeq returns a sequence for use with range which repeatedly takes the
+// value out of the [Value] (as by [Value.Take]), yielding each one, and ends
+// as soon as the Value is found unset. This suits a single-slot mailbox which
+// producers overwrite: each value yielded was taken from the Value, so is
+// yielded to no other consumer, and a value stored while the loop body runs is
+// yielded next. The sequence doesn't wait for new values; see [Value.Observe]
+// for that.
+func (v *Value[T]) DrainSeq() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			val, ok := v.Take()
+			if !ok || !yield(val) {
+				return
+			}
+		}
+	}
 }
 
 // Swap stores new into Value and returns the previous value. Returns the zero value
 // if no value has been set.
 func (v *Value[T]) Swap(new T) (old T) {
-	dp := atomic.SwapPointer(&v.v, unsafe.Pointer(&[1]T{new}))
+	v.stats.swap()
+	box := unsafe.Pointer(&[1]T{new})
+	dp := atomic.SwapPointer(&v.v, box)
+	v.changed(dp, box)
 	if dp == nil {
 		return old
 	}
@@ -57,29 +215,445 @@ func (v *Value[T]) Swap(new T) (old T) {
 	return (*[1]T)(dp)[0]
 }
 
+// SwapAndWasSet is like [Value.Swap], but also reports whether a value had been
+// set, distinguishing a previously unset [Value] from one holding the zero
+// value.
+func (v *Value[T]) SwapAndWasSet(new T) (old T, wasSet bool) {
+	box := unsafe.Pointer(&[1]T{new})
+	dp := atomic.SwapPointer(&v.v, box)
+	v.changed(dp, box)
+	if dp == nil {
+		return old, false
+	}
+
+	return *(*T)(dp), true
+}
+
 // CompareAndSwap executes the compare-and-swap operation for the [Value]. All
 // values of type T are valid inputs. If no value has been set, old is compared
 // against the zero-value for type T.
+//
+// Comparing interfaces whose dynamic types are not comparable (e.g. a struct
+// field of type any holding a slice) would panic with ==; here such values are
+// simply considered unequal, and CompareAndSwap returns false.
 func (v *Value[T]) CompareAndSwap(old, new T) (swapped bool) {
+	swapped = v.compareAndSwap(&old, new)
+	v.stats.compareAndSwap(swapped)
+	return swapped
+}
+
+// CompareAndSwapPtr is like [Value.CompareAndSwap], but takes the expected
+// value by pointer, which must not be nil. This saves copying a large old into
+// the call, which is noticeable when the comparison fails (new is copied into
+// a newly allocated box either way, which dominates when it succeeds). *old
+// is read during the call, so must not be modified concurrently.
+func (v *Value[T]) CompareAndSwapPtr(old *T, new T) (swapped bool) {
+	swapped = v.compareAndSwap(old, new)
+	v.stats.compareAndSwap(swapped)
+	return swapped
+}
+
+func (v *Value[T]) compareAndSwap(old *T, new T) (swapped bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		dp := atomic.LoadPointer(&v.v)
+		if !boxEqual(dp, old) {
+			return false
+		}
+
+		if box == nil {
+			box = unsafe.Pointer(&[1]T{new})
+		}
+
+		// the pointer comparison ensures that changes haven't occurred since
+		// the [atomic.LoadPointer] call above. If one has, the new value may
+		// still equal old, so go back and compare again
+		if v.compareAndSwapBox(dp, box) {
+			return true
+		}
+	}
+}
+
+// CompareAndSwapWeak is like [Value.CompareAndSwap], but may fail spuriously:
+// if the value is replaced concurrently, even by one equal to old, it returns
+// false rather than comparing again. It makes a single attempt, so callers
+// must be prepared to retry, typically in a loop which recomputes new from the
+// latest value anyway. Such a loop does no redundant comparisons; otherwise,
+// prefer CompareAndSwap.
+func (v *Value[T]) CompareAndSwapWeak(old, new T) (swapped bool) {
 	dp := atomic.LoadPointer(&v.v)
-	if dp == nil {
-		// treat nil as a zero-value, otherwise proceeding as below
+	if !boxEqual(dp, &old) {
+		return false
+	}
+
+	return v.compareAndSwapBox(dp, unsafe.Pointer(&[1]T{new}))
+}
+
+// boxEqual reports whether the value in box equals val, as compared by
+// [Value.CompareAndSwap]. A nil box holds the zero value.
+func boxEqual[T comparable](box unsafe.Pointer, val *T) bool {
+	if box == nil {
 		var zeroVal T
-		if old != zeroVal {
+		return equal(val, &zeroVal)
+	}
+
+	return equal((*T)(box), val)
+}
+
+// CompareAndDelete resets the [Value] to unset if its current value equals old
+// (compared as in [Value.CompareAndSwap]), reporting whether it did so. Unlike
+// CompareAndSwap, an unset Value is not treated as holding the zero value here:
+// there is nothing to delete, so CompareAndDelete returns false for any old.
+func (v *Value[T]) CompareAndDelete(old T) (deleted bool) {
+	for {
+		dp := atomic.LoadPointer(&v.v)
+		if dp == nil || !equal((*T)(dp), &old) {
+			return false
+		}
+
+		// as in [Value.CompareAndSwap], compare again if the value was
+		// replaced in the meantime
+		if v.compareAndSwapBox(dp, nil) {
+			return true
+		}
+	}
+}
+
+// CompareAndSwapFunc is like [Value.CompareAndSwap], but uses eq rather than
+// == to compare the current value against old. If no value has been set, the
+// current value is the zero value for type T, so eq(zeroVal, old) decides
+// whether the swap proceeds. eq is always passed the current value first.
+func (v *Value[T]) CompareAndSwapFunc(old, new T, eq func(current, old T) bool) (swapped bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		dp := atomic.LoadPointer(&v.v)
+		if !eq(boxed[T](dp), old) {
+			return false
+		}
+
+		if box == nil {
+			box = unsafe.Pointer(&[1]T{new})
+		}
+
+		// as in [Value.CompareAndSwap], compare again if the value was
+		// replaced in the meantime
+		if v.compareAndSwapBox(dp, box) {
+			return true
+		}
+	}
+}
+
+// LoadOrStore returns the current value if one has been set, with loaded true.
+// Otherwise it stores val and returns it, with loaded false. Only the unset
+// state counts as empty: a [Value] holding the zero value is loaded as-is. Of
+// several goroutines racing to initialize an unset [Value], exactly one stores
+// its val, and all of them return that value.
+func (v *Value[T]) LoadOrStore(val T) (actual T, loaded bool) {
+	dp := atomic.LoadPointer(&v.v)
+	if dp != nil {
+		return *(*T)(dp), true
+	}
+
+	box := unsafe.Pointer(&[1]T{val})
+	for {
+		if v.compareAndSwapBox(nil, box) {
+			return val, false
+		}
+
+		if dp = atomic.LoadPointer(&v.v); dp != nil {
+			return *(*T)(dp), true
+		}
+	}
+}
+
+// TryStore stores val only if no value has been set, reporting whether it did
+// so. Like [Value.LoadOrStore], only the unset state counts as empty: once any
+// value has been set, even the zero value, TryStore returns false. Of several
+// goroutines racing to TryStore on an unset [Value], exactly one succeeds.
+func (v *Value[T]) TryStore(val T) (stored bool) {
+	if atomic.LoadPointer(&v.v) != nil {
+		return false
+	}
+
+	return v.compareAndSwapBox(nil, unsafe.Pointer(&[1]T{val}))
+}
+
+// StoreIfChanged stores val unless the [Value] already holds an equal value
+// (compared as in [Value.CompareAndSwap]), reporting whether it stored. An
+// unchanged value costs neither an allocation nor a change notification. An
+// unset Value counts as changed by any store, even of the zero value, which
+// it then holds.
+func (v *Value[T]) StoreIfChanged(val T) (stored bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		dp := atomic.LoadPointer(&v.v)
+		if dp != nil && equal((*T)(dp), &val) {
 			return false
 		}
 
-		return atomic.CompareAndSwapPointer(&v.v, dp, unsafe.Pointer(&[1]T{new}))
+		if box == nil {
+			box = unsafe.Pointer(&[1]T{val})
+		}
+
+		// if this fails, the value changed after the comparison above, and
+		// may now equal val
+		if v.compareAndSwapBox(dp, box) {
+			return true
+		}
+	}
+}
+
+// Update atomically replaces the current value with the result of fn, which is
+// passed the current value (or the zero value, if no value has been set).
+//
+// If another goroutine modifies the [Value] while fn is running, fn is called
+// again with the new current value, so fn may be called any number of times and
+// should have no side effects. Only the result of the final call is stored.
+func (v *Value[T]) Update(fn func(old T) (new T)) {
+	v.update(fn)
+}
+
+// GetAndUpdate is like [Value.Update], but returns the value that was replaced.
+func (v *Value[T]) GetAndUpdate(fn func(old T) (new T)) (old T) {
+	old, _ = v.update(fn)
+	return old
+}
+
+// UpdateAndGet is like [Value.Update], but returns the value that was stored.
+func (v *Value[T]) UpdateAndGet(fn func(old T) (new T)) (new T) {
+	_, new = v.update(fn)
+	return new
+}
+
+// UpdateContext is like [Value.UpdateAndGet], but gives up once ctx is done,
+// bounding the time spent retrying under heavy contention. ctx is checked
+// before each call of fn, so fn is never called after ctx is done, and
+// between attempts the goroutine yields to let competing writers finish. If
+// ctx is done before an attempt succeeds, UpdateContext returns the last
+// result of fn (the zero value, if fn was not called) and ctx's error,
+// leaving the Value unchanged.
+func (v *Value[T]) UpdateContext(ctx context.Context, fn func(old T) (new T)) (T, error) {
+	var box *[1]T // allocated once, reused across attempts until published
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			runtime.Gosched()
+		}
+
+		if err := ctx.Err(); err != nil {
+			if box == nil {
+				var zeroVal T
+				return zeroVal, err
+			}
+
+			return box[0], err
+		}
+
+		dp := atomic.LoadPointer(&v.v)
+		if box == nil {
+			box = new([1]T)
+		}
+
+		box[0] = fn(boxed[T](dp))
+
+		if v.compareAndSwapBox(dp, unsafe.Pointer(box)) {
+			return box[0], nil
+		}
+	}
+}
+
+// update implements the Update family of methods, returning the replaced and
+// stored values.
+func (v *Value[T]) update(fn func(old T) T) (old, stored T) {
+	var box *[1]T // allocated once, reused across attempts until published
+	for {
+		var zeroVal T
+		old = zeroVal
+		dp := atomic.LoadPointer(&v.v)
+		if dp != nil {
+			old = *(*T)(dp)
+		}
+
+		if box == nil {
+			box = new([1]T)
+		}
+
+		box[0] = fn(old)
+
+		// fails if anything was published since the load above, even an equal
+		// value, since fn may not have been called with the current state
+		if v.compareAndSwapBox(dp, unsafe.Pointer(box)) {
+			return old, box[0]
+		}
+	}
+}
+
+// Modify is like [Value.Update], but mutate modifies a copy of the current value
+// in place, which is then stored. This saves copying a large T in and out of fn,
+// and lets several fields be changed with a single publish.
+//
+// Each call of mutate gets a fresh copy of the current value, so changes made
+// by an attempt which has to be retried are discarded. The copy is shallow,
+// though: memory the value refers to (e.g. a slice's elements) is shared with
+// the current value, and must not be modified in place. mutate must not retain
+// the pointer.
+func (v *Value[T]) Modify(mutate func(val *T)) {
+	var box *[1]T // allocated once, reused across attempts until published
+	for {
+		dp := atomic.LoadPointer(&v.v)
+		if box == nil {
+			box = new([1]T)
+		}
+
+		box[0] = boxed[T](dp)
+		mutate(&box[0])
+
+		if v.compareAndSwapBox(dp, unsafe.Pointer(box)) {
+			return
+		}
+	}
+}
+
+// StoreFunc stores the result of fn, which derives the value from state
+// outside the [Value]. Unlike [Value.Update], fn is not passed the current
+// value. Like Update, if another goroutine modifies the Value while fn is
+// running, fn is called again, so a concurrent mutation is never silently
+// overwritten by a result computed before it took effect. fn may therefore be
+// called any number of times, and should have no side effects; only the
+// result of the final call is stored.
+func (v *Value[T]) StoreFunc(fn func() T) {
+	var box *[1]T // allocated once, reused across attempts until published
+	for {
+		dp := atomic.LoadPointer(&v.v)
+		if box == nil {
+			box = new([1]T)
+		}
+
+		box[0] = fn()
+
+		if v.compareAndSwapBox(dp, unsafe.Pointer(box)) {
+			return
+		}
 	}
+}
 
-	// Perform a runtime equality check between old and the current value
-	if *(*[1]T)(dp) != [1]T{old} {
+// StoreDuring stores val only if window reports that the current time is
+// within an allowed window (e.g. outside of a change freeze), returning whether
+// the store was applied. A rejected store leaves the value unchanged.
+func (v *Value[T]) StoreDuring(val T, window func(time.Time) bool) (applied bool) {
+	if !window(timeNow()) {
 		return false
 	}
 
-	// [atomic.CompareAndSwapPointer] ensures that changes haven't occurred since the
-	// [atomic.LoadPointer] call above
-	return atomic.CompareAndSwapPointer(&v.v, dp, unsafe.Pointer(&[1]T{new}))
+	v.Store(val)
+	return true
+}
+
+// Accessors returns closures equivalent to v.Load and v.Store, allowing read
+// and write capabilities to be handed out separately without sharing v
+// itself.
+func (v *Value[T]) Accessors() (get func() T, set func(T)) {
+	return v.Load, v.Store
+}
+
+// Clone returns a new [Value] holding the current value of v, or an unset one
+// if v is unset. The two are independent: later changes to either do not
+// affect the other.
+func (v *Value[T]) Clone() *Value[T] {
+	c := new(Value[T])
+	if dp := atomic.LoadPointer(&v.v); dp != nil {
+		c.v = unsafe.Pointer(&[1]T{*(*T)(dp)})
+	}
+
+	return c
+}
+
+// Equal reports whether v and other hold equal values (by ==, with
+// incomparable dynamic types considered unequal as for [Value.CompareAndSwap]),
+// or are both unset. An unset Value is not equal to one holding the zero value,
+// and a Value is always equal to itself.
+//
+// The two Values are loaded separately, so the result reflects each at some
+// point during the call, but not necessarily at the same instant.
+func (v *Value[T]) Equal(other *Value[T]) bool {
+	if v == other {
+		return true
+	}
+
+	a, b := atomic.LoadPointer(&v.v), atomic.LoadPointer(&other.v)
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return equal((*T)(a), (*T)(b))
+}
+
+// CompareAndSwapDiff is like [Value.CompareAndSwap], except that when the swap
+// fails it calls diff with old and the current value it was compared against,
+// returning the result. This lets callers learn how a conflicting value differs
+// from what they expected, e.g. to perform a three-way merge. A zero D is
+// returned when the swap succeeds, and diff is called at most once.
+func CompareAndSwapDiff[T comparable, D any](v *Value[T], old, new T, diff func(expected, actual T) D) (d D, swapped bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		var cur T
+		dp := atomic.LoadPointer(&v.v)
+		if dp != nil {
+			cur = *(*T)(dp)
+		}
+
+		if !equal(&cur, &old) {
+			return diff(old, cur), false
+		}
+
+		if box == nil {
+			box = unsafe.Pointer(&[1]T{new})
+		}
+
+		// if this fails, the value changed after the comparison above, so go
+		// back and witness the new one
+		if v.compareAndSwapBox(dp, box) {
+			return d, true
+		}
+	}
+}
+
+// StoreAny stores val in v if it holds a T, returning an error and leaving v
+// unchanged otherwise. This bridges dynamically-typed inputs (e.g. from a
+// generic config loader) into a typed [Value]. A nil val is accepted only if T
+// is an interface type, and stores the nil interface.
+func StoreAny[T comparable](v *Value[T], val any) error {
+	typed, ok := val.(T)
+	if !ok {
+		var zeroVal T
+		if val != nil || any(zeroVal) != nil {
+			return fmt.Errorf("atomicval: cannot store %T in Value[%s]", val, reflect.TypeFor[T]())
+		}
+	}
+
+	v.Store(typed)
+	return nil
+}
+
+// compareAndSwapBox publishes new in place of old if the current box is
+// still old, notifying observers if it succeeds.
+func (v *Value[T]) compareAndSwapBox(old, new unsafe.Pointer) (swapped bool) {
+	if beforeCAS != nil {
+		beforeCAS(&v.v)
+	}
+
+	if !atomic.CompareAndSwapPointer(&v.v, old, new) {
+		return false
+	}
+
+	v.changed(old, new)
+	return true
+}
+
+// equal reports whether a == b. The runtime panic raised by comparing
+// incomparable dynamic types is recovered and reported as inequality.
+func equal[T comparable](a, b *T) (eq bool) {
+	defer func() { _ = recover() }()
+	return *a == *b
 }
 
 // noCopy may be added to structs which must not be copied
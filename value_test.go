@@ -2,15 +2,19 @@ package atomicval
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"math"
 	"math/rand/v2"
 	"runtime"
 	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
+	"unsafe"
 )
 
 type ex struct {
@@ -19,6 +23,151 @@ type ex struct {
 	c complex128
 }
 
+func TestNew(t *testing.T) {
+	a := New(0)
+	requireEqual(t, true, a.IsSet())
+	requireEqual(t, 0, a.Load())
+
+	b := New("b")
+	requireEqual(t, "b", b.Load())
+	requireEqual(t, true, b.CompareAndSwap("b", "c"))
+
+	var w io.Writer = new(bytes.Buffer)
+	c := New(w)
+	requireEqual(t, w, c.Load())
+
+	u := NewUnset[int]()
+	requireEqual(t, false, u.IsSet())
+	requireEqual(t, 0, u.Load())
+	requireEqual(t, true, u.CompareAndSwap(0, 1))
+}
+
+func TestValue_alignment(t *testing.T) {
+	const ptrAlign = unsafe.Alignof(unsafe.Pointer(nil))
+
+	check := func(t *testing.T, addr unsafe.Pointer) {
+		t.Helper()
+		if uintptr(addr)%ptrAlign != 0 {
+			t.Fatalf("misaligned atomic word at %p", addr)
+		}
+	}
+
+	// within unaligned neighbours, in structs and arrays
+	var s struct {
+		a byte
+		b Value[byte]
+		c [3]byte
+		d Value[[3]byte]
+		e bool
+		f Value[bool]
+	}
+	check(t, unsafe.Pointer(&s.b.v))
+	check(t, unsafe.Pointer(&s.d.v))
+	check(t, unsafe.Pointer(&s.f.v))
+
+	var arr [3]Value[byte]
+	for i := range arr {
+		check(t, unsafe.Pointer(&arr[i].v))
+	}
+
+	requireEqual(t, uintptr(0), unsafe.Alignof(Value[byte]{})%ptrAlign)
+	requireEqual(t, 2*unsafe.Sizeof(unsafe.Pointer(nil))+unsafe.Sizeof(opStats{}), unsafe.Sizeof(Value[byte]{}))
+
+	// heap allocated, including by New
+	check(t, unsafe.Pointer(&New(byte(1)).v))
+	check(t, unsafe.Pointer(&new(Numeric[int8]).v))
+}
+
+func TestValueOf(t *testing.T) {
+	check := func(t *testing.T, set, unset interface{ IsSet() bool }) {
+		t.Helper()
+		requireEqual(t, true, set.IsSet())
+		requireEqual(t, false, unset.IsSet())
+	}
+
+	t.Run("scalar", func(t *testing.T) {
+		v := ValueOf(3.5)
+		requireEqual(t, 3.5, v.Load())
+		check(t, v, ZeroValue[float64]())
+
+		// the zero value is still set
+		check(t, ValueOf(0), ZeroValue[int]())
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		v := ValueOf(ex{1, "1", 1i})
+		requireEqual(t, ex{1, "1", 1i}, v.Load())
+		check(t, v, ZeroValue[ex]())
+		check(t, ValueOf(ex{}), ZeroValue[ex]())
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		p := new(int)
+		v := ValueOf(p)
+		requireEqual(t, p, v.Load())
+		check(t, v, ZeroValue[*int]())
+		check(t, ValueOf[*int](nil), ZeroValue[*int]())
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		v := ValueOf[io.Writer](io.Discard)
+		requireEqual(t, io.Discard, v.Load())
+		requireEqual(t, true, v.CompareAndSwap(io.Discard, fakeWriter{}))
+		check(t, v, ZeroValue[io.Writer]())
+		check(t, ValueOf[io.Writer](nil), ZeroValue[io.Writer]())
+	})
+
+	t.Run("allocations", func(t *testing.T) {
+		// the Value, and the box holding the initial value; both escape via sink
+		var sink atomic.Pointer[Value[ex]]
+		requireEqual(t, 2.0, testing.AllocsPerRun(100, func() { sink.Store(ValueOf(ex{})) }))
+		requireEqual(t, 1.0, testing.AllocsPerRun(100, func() { sink.Store(ZeroValue[ex]()) }))
+	})
+}
+
+// TestValue_copied checks that a Value copied despite the noCopy guard (see
+// TestValue_vetCopylocks) shares no mutable state with the original.
+func TestValue_copied(t *testing.T) {
+	var a Value[int]
+	a.Store(1)
+	var changes []int
+	a.SetOnChange(func(_, new int) { changes = append(changes, new) })
+	ch, cancel := a.Subscribe()
+	defer cancel()
+
+	// vet doesn't follow copies through a dereference
+	b := *(*Value[int])(unsafe.Pointer(&a))
+	requireEqual(t, 1, b.Load())
+
+	// mutating the copy neither affects nor notifies the original
+	b.Store(2)
+	b.Swap(3)
+	requireEqual(t, true, b.CompareAndSwap(3, 4))
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, 4, b.Load())
+	requireEqual(t, 0, len(changes))
+	select {
+	case val := <-ch:
+		t.Fatalf("copy notified subscriber of original: %d", val)
+	default:
+	}
+
+	// the copy can be observed separately
+	var copyChanges []int
+	b.SetOnChange(func(_, new int) { copyChanges = append(copyChanges, new) })
+	b.Store(5)
+	a.Store(6)
+	requireEqual(t, "[5]", fmt.Sprint(copyChanges))
+	requireEqual(t, "[6]", fmt.Sprint(changes))
+	requireEqual(t, 6, <-ch)
+
+	// and the original's observers are unaffected by removing the copy's
+	b.SetOnChange(nil)
+	requireEqual(t, (*hooks[int])(nil), b.hooks.Load())
+	a.Store(7)
+	requireEqual(t, "[6 7]", fmt.Sprint(changes))
+}
+
 func TestValue_Load(t *testing.T) {
 	requireZero(t, new(Value[int]).Load())
 	requireZero(t, new(Value[[2]int]).Load())
@@ -38,6 +187,11 @@ type fakeWriter2 fakeWriter
 
 func (fakeWriter2) Write(p []byte) (int, error) { return len(p), nil }
 
+// sliceWriter is an io.Writer whose dynamic type is not comparable.
+type sliceWriter []byte
+
+func (w sliceWriter) Write(p []byte) (int, error) { return len(p), nil }
+
 func TestValue_LoadAndStore(t *testing.T) {
 	var a Value[uint64]
 	a.Store(1)
@@ -91,120 +245,1616 @@ func TestValue_LoadAndStore(t *testing.T) {
 		requireNotEqual(t, res1, res2)
 	})
 
-	// based on stdlib test
+	// based on stdlib test
+	t.Run("concurrent", func(t *testing.T) {
+		// generate a slice of random data -- lengths/sizes are mostly arbitrary
+		randArr := func() [3]uint64 {
+			return [3]uint64{rand.Uint64(), rand.Uint64(), rand.Uint64()}
+		}
+		data := [][3]uint64{randArr(), randArr(), randArr(), randArr()}
+
+		paralellism := 100 * runtime.GOMAXPROCS(0)
+		iters := 50000
+		if testing.Short() {
+			iters = 10000
+		}
+
+		wg := sync.WaitGroup{}
+		wg.Add(paralellism)
+		failChan := make(chan error)
+		go func() {
+			wg.Wait()
+			close(failChan)
+		}()
+
+		var av Value[[3]uint64]
+		for range paralellism {
+			go func() {
+				defer wg.Done()
+				for range iters {
+					x := data[rand.IntN(len(data))]
+					av.Store(x)
+					x = av.Load()
+
+					if !slices.Contains(data, x) {
+						failChan <- fmt.Errorf("value %+v not in test data set: %+v", x, data)
+					}
+				}
+			}()
+		}
+
+		for err := range failChan {
+			t.Fatal(err)
+		}
+	})
+}
+
+// TestValue_publication checks that a mutation publishes everything written
+// before it: a reader which observes a value must also observe the writes that
+// initialized it, both in the stored copy and in memory it points to. Most
+// useful under -race, which reports any missing happens-before edge.
+func TestValue_publication(t *testing.T) {
+	type rec struct {
+		seq     int
+		a, b, c int
+		data    *[8]int // written through after allocation, before publishing
+	}
+
+	newRec := func(seq int) rec {
+		r := rec{seq: seq, data: new([8]int)}
+		r.a, r.b, r.c = seq, seq*2, seq*3
+		for i := range r.data {
+			r.data[i] = seq
+		}
+		return r
+	}
+
+	check := func(r rec) error {
+		if r.data == nil {
+			if r != (rec{}) {
+				return fmt.Errorf("partially initialized: %+v", r)
+			}
+			return nil
+		}
+
+		if r.a != r.seq || r.b != r.seq*2 || r.c != r.seq*3 {
+			return fmt.Errorf("torn fields: %+v", r)
+		}
+		for _, d := range r.data {
+			if d != r.seq {
+				return fmt.Errorf("unpublished data for %d: %v", r.seq, *r.data)
+			}
+		}
+		return nil
+	}
+
+	iters := 20000
+	if testing.Short() {
+		iters = 2000
+	}
+	readers := 2 * runtime.GOMAXPROCS(0)
+
+	run := func(t *testing.T, publish func(seq int), load func() rec) {
+		var done atomic.Bool
+		var wg sync.WaitGroup
+		errs := make(chan error, readers)
+		for range readers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				last := 0
+				for !done.Load() {
+					r := load()
+					if err := check(r); err != nil {
+						errs <- err
+						return
+					}
+					if r.seq < last {
+						errs <- fmt.Errorf("went back from %d to %d", last, r.seq)
+						return
+					}
+					last = r.seq
+				}
+			}()
+		}
+
+		for seq := 1; seq <= iters; seq++ {
+			publish(seq)
+		}
+		done.Store(true)
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Store", func(t *testing.T) {
+		var av Value[rec]
+		run(t, func(seq int) { av.Store(newRec(seq)) }, av.Load)
+	})
+
+	t.Run("Swap", func(t *testing.T) {
+		var av Value[rec]
+		run(t, func(seq int) { av.Swap(newRec(seq)) }, av.Load)
+	})
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		var av Value[rec]
+		run(t, func(seq int) {
+			for !av.CompareAndSwap(av.Load(), newRec(seq)) {
+			}
+		}, av.Load)
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		var av Value[*rec]
+		run(t, func(seq int) {
+			r := newRec(seq)
+			av.Store(&r)
+		}, func() rec {
+			if p := av.Load(); p != nil {
+				return *p
+			}
+			return rec{}
+		})
+	})
+}
+
+func TestValue_Swap(t *testing.T) {
+	var a Value[uint64]
+	requireEqual(t, uint64(0), a.Swap(1))
+	requireEqual(t, uint64(1), a.Swap(math.MaxUint64))
+	requireEqual(t, uint64(math.MaxUint64), a.Swap(0))
+
+	var b Value[*int]
+	ptrA := new(int)
+	requireZero(t, b.Swap(ptrA))
+	requireEqual(t, ptrA, b.Swap(new(int)))
+	requireNotZero(t, b.Swap(nil))
+	requireZero(t, b.Swap(ptrA))
+
+	var c Value[io.Writer]
+	requireZero(t, c.Swap(io.Discard))
+	requireEqual(t, io.Discard, c.Swap(io.Discard))
+	buf := bytes.NewBuffer(nil)
+	requireEqual(t, io.Discard, c.Swap(buf))
+	requireEqual[io.Writer](t, buf, c.Swap(nil))
+
+	// based on stdlib test
+	t.Run("concurrent", func(t *testing.T) {
+		// calculate the sum of integers 1...N-1 by addition, splitting the range
+		// into chunks per goroutine, then compare the result against a known
+		// formula.
+		var chunks, chunkSize uint64 = 1000, 100000
+		if testing.Short() {
+			chunks, chunkSize = 1000, 1000
+		}
+		N := chunkSize * chunks
+
+		// 1 + 2 + 3 ... N-1 = N * (N - 1) / 2
+		expected := (N - 1) * N / 2
+
+		var wg sync.WaitGroup
+		var sum atomic.Uint64
+		var av Value[uint64]
+		for start := uint64(0); start < N; start += chunkSize {
+			wg.Add(1)
+			go func(start uint64) {
+				var localSum uint64 // avoid [atomic.Uint64.Add] bottleneck
+				for x := range chunkSize {
+					localSum += av.Swap(start + x)
+				}
+				sum.Add(localSum)
+				wg.Done()
+			}(uint64(start))
+		}
+		wg.Wait()
+
+		// the value swapped in by the last [Value.Swap] won't have been returned by a
+		// subsequent call, so add it here from [Value.Load]
+		sum.Add(av.Load())
+		requireEqual(t, expected, sum.Load())
+	})
+}
+
+func TestValue_SwapAndWasSet(t *testing.T) {
+	var a Value[int]
+	old, wasSet := a.SwapAndWasSet(0)
+	requireZero(t, old)
+	requireEqual(t, false, wasSet)
+
+	old, wasSet = a.SwapAndWasSet(1)
+	requireZero(t, old)
+	requireEqual(t, true, wasSet)
+
+	old, wasSet = a.SwapAndWasSet(2)
+	requireEqual(t, 1, old)
+	requireEqual(t, true, wasSet)
+
+	a.Reset()
+	old, wasSet = a.SwapAndWasSet(3)
+	requireZero(t, old)
+	requireEqual(t, false, wasSet)
+	requireEqual(t, 3, a.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		// after a Reset, exactly one of many racing swaps initializes the value
+		n := 4 * runtime.GOMAXPROCS(0)
+		rounds := 1000
+		if testing.Short() {
+			rounds = 100
+		}
+
+		var av Value[int]
+		for range rounds {
+			av.Reset()
+
+			var inits atomic.Int32
+			var wg sync.WaitGroup
+			for i := range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, wasSet := av.SwapAndWasSet(i); !wasSet {
+						inits.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			requireEqual(t, int32(1), inits.Load())
+		}
+	})
+}
+
+func TestValue_CompareAndSwap(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, true, a.CompareAndSwap(0, 1))
+	requireEqual(t, true, a.CompareAndSwap(1, 2))
+	requireEqual(t, false, a.CompareAndSwap(3, 4))
+
+	var b Value[io.Writer]
+	requireEqual(t, true, b.CompareAndSwap(nil, io.Discard))
+	requireEqual(t, false, b.CompareAndSwap(nil, io.Discard))
+	requireEqual(t, true, b.CompareAndSwap(io.Discard, nil))
+	requireEqual(t, false, b.CompareAndSwap(io.Discard, nil))
+
+	t.Run("incomparable interface implementations", func(t *testing.T) {
+		var v Value[io.Writer]
+		sw := sliceWriter("a")
+
+		// as old, or as the current value: never equal, never a panic
+		requireEqual(t, false, v.CompareAndSwap(sw, io.Discard))
+		requireEqual(t, true, v.CompareAndSwap(nil, sw))
+		requireEqual(t, false, v.CompareAndSwap(sw, io.Discard))
+		requireEqual(t, false, v.CompareAndSwap(io.Discard, nil))
+		requireEqual(t, false, v.CompareAndSwapWeak(sw, io.Discard))
+		requireEqual(t, false, v.CompareAndDelete(sw))
+		requireEqual(t, false, v.Equal(New[io.Writer](sw)))
+		requireEqual(t, true, v.Equal(&v))
+		_, swapped := CompareAndSwapDiff[io.Writer](&v, sw, io.Discard, func(_, _ io.Writer) int { return 0 })
+		requireEqual(t, false, swapped)
+		if _, ok := v.Load().(sliceWriter); !ok {
+			t.Fatalf("unexpected value: %+v", v.Load())
+		}
+
+		// other methods don't compare, and work as usual
+		requireEqual(t, "a", string(v.Swap(io.Discard).(sliceWriter)))
+		requireEqual(t, true, v.CompareAndSwap(io.Discard, sw))
+		requireEqual(t, "a", string(v.LoadAndReset().(sliceWriter)))
+	})
+
+	t.Run("incomparable dynamic types", func(t *testing.T) {
+		type holder struct{ X any }
+
+		var v Value[holder]
+		requireEqual(t, false, v.CompareAndSwap(holder{[]int{1}}, holder{1}))
+		requireEqual(t, true, v.CompareAndSwap(holder{}, holder{[]int{1}}))
+
+		// comparing against a stored slice is always unequal, even to itself
+		requireEqual(t, false, v.CompareAndSwap(holder{[]int{1}}, holder{2}))
+		requireEqual(t, false, v.CompareAndSwap(holder{1}, holder{2}))
+		if _, ok := v.Load().X.([]int); !ok {
+			t.Fatalf("unexpected value: %+v", v.Load())
+		}
+
+		v.Store(holder{1})
+		requireEqual(t, false, v.CompareAndSwap(holder{[]int{1}}, holder{2}))
+		requireEqual(t, true, v.CompareAndSwap(holder{1}, holder{2}))
+		requireEqual(t, holder{2}, v.Load())
+	})
+
+	t.Run("allocations", func(t *testing.T) {
+		type large [32]uint8
+		var x, y large
+		y[len(y)-1] = 1
+
+		var av Value[large]
+		av.Store(x)
+
+		// a failed comparison neither copies old into a box nor allocates one
+		// for new; only a successful swap allocates
+		requireEqual(t, 0.0, testing.AllocsPerRun(100, func() { av.CompareAndSwap(y, x) }))
+		requireEqual(t, 2.0, testing.AllocsPerRun(100, func() {
+			av.CompareAndSwap(x, y)
+			av.CompareAndSwap(y, x)
+		}))
+
+		var unset Value[large]
+		requireEqual(t, 0.0, testing.AllocsPerRun(100, func() { unset.CompareAndSwap(y, x) }))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		n := 10000
+		if testing.Short() {
+			n = 1000
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+
+		var av Value[int]
+
+		// "i" counts down to maximize contention
+		for i := n - 1; i >= 0; i-- {
+			go func(i int) {
+				// each goroutine must succeed exactly once (no values are "missed")
+				for !av.CompareAndSwap(i, i+1) {
+					runtime.Gosched()
+				}
+				wg.Done()
+			}(i)
+		}
+
+		wg.Wait()
+	})
+
+	t.Run("equal replacement", func(t *testing.T) {
+		iters := 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		// the value is always 1, though it is continually replaced: the
+		// comparison must never fail
+		av := New(1)
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					av.Store(1)
+				}
+			}
+		}()
+		defer close(done)
+
+		for range iters {
+			if !av.CompareAndSwap(1, 1) {
+				t.Fatal("failed despite matching value")
+			}
+		}
+	})
+}
+
+func TestValue_CompareAndSwapWeak(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, false, a.CompareAndSwapWeak(1, 2))
+	requireEqual(t, false, a.IsSet())
+	requireEqual(t, true, a.CompareAndSwapWeak(0, 1))
+	requireEqual(t, false, a.CompareAndSwapWeak(0, 2))
+	requireEqual(t, true, a.CompareAndSwapWeak(1, 2))
+	requireEqual(t, 2, a.Load())
+
+	var b Value[any]
+	b.Store([]int{1})
+	requireEqual(t, false, b.CompareAndSwapWeak([]int{1}, 2))
+
+	t.Run("concurrent", func(t *testing.T) {
+		n := 1000
+		if testing.Short() {
+			n = 100
+		}
+
+		// retried in a loop, each goroutine's increment lands exactly once
+		var av Value[int]
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					old := av.Load()
+					if av.CompareAndSwapWeak(old, old+1) {
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, n, av.Load())
+	})
+}
+
+func TestValue_CompareAndSwapPtr(t *testing.T) {
+	type large [256]byte
+	var x, y large
+	y[len(y)-1] = 1
+
+	// the same results as CompareAndSwap, including from unset
+	var a, b Value[large]
+	for _, c := range []struct{ old, new large }{
+		{y, x}, {x, y}, {x, y}, {y, y}, {y, x},
+	} {
+		requireEqual(t, a.CompareAndSwap(c.old, c.new), b.CompareAndSwapPtr(&c.old, c.new))
+		requireEqual(t, a.Load(), b.Load())
+		requireEqual(t, a.IsSet(), b.IsSet())
+	}
+
+	var w Value[io.Writer]
+	sw := io.Writer(sliceWriter("a"))
+	requireEqual(t, false, w.CompareAndSwapPtr(&sw, io.Discard))
+	requireEqual(t, false, w.IsSet())
+	requireEqual(t, true, w.CompareAndSwapPtr(new(io.Writer), sw))
+	requireEqual(t, false, w.CompareAndSwapPtr(&sw, nil))
+}
+
+func TestValue_StoreDuring(t *testing.T) {
+	// fake clock
+	fakeNow := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return fakeNow }
+	defer func() { timeNow = time.Now }()
+
+	// only allow changes between 02:00 and 04:00
+	maintenance := func(now time.Time) bool {
+		return now.Hour() >= 2 && now.Hour() < 4
+	}
+
+	var v Value[string]
+	v.Store("initial")
+
+	requireEqual(t, false, v.StoreDuring("rejected", maintenance))
+	requireEqual(t, "initial", v.Load())
+
+	fakeNow = time.Date(2024, 1, 2, 2, 30, 0, 0, time.UTC)
+	requireEqual(t, true, v.StoreDuring("applied", maintenance))
+	requireEqual(t, "applied", v.Load())
+
+	fakeNow = time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)
+	requireEqual(t, false, v.StoreDuring("rejected again", maintenance))
+	requireEqual(t, "applied", v.Load())
+
+	// window sees the hooked clock
+	var seen time.Time
+	v.StoreDuring("x", func(now time.Time) bool { seen = now; return false })
+	requireEqual(t, fakeNow, seen)
+}
+
+func TestValue_CompareAndDelete(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, false, a.CompareAndDelete(0))
+
+	a.Store(0)
+	requireEqual(t, false, a.CompareAndDelete(1))
+	requireEqual(t, true, a.IsSet())
+	requireEqual(t, true, a.CompareAndDelete(0))
+	requireEqual(t, false, a.IsSet())
+	requireEqual(t, false, a.CompareAndDelete(0))
+
+	var x Value[any]
+	x.Store([]int{1})
+	requireEqual(t, false, x.CompareAndDelete([]int{1}))
+	requireEqual(t, true, x.IsSet())
+
+	t.Run("concurrent", func(t *testing.T) {
+		// a claimed slot is released by exactly one of many deleters
+		n := 4 * runtime.GOMAXPROCS(0)
+		rounds := 1000
+		if testing.Short() {
+			rounds = 100
+		}
+
+		var slot Value[string]
+		for range rounds {
+			slot.Store("claimed")
+
+			var wins atomic.Int32
+			var wg sync.WaitGroup
+			for range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if slot.CompareAndDelete("claimed") {
+						wins.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			requireEqual(t, int32(1), wins.Load())
+			requireEqual(t, false, slot.IsSet())
+		}
+	})
+}
+
+func TestValue_CompareAndSwapFunc(t *testing.T) {
+	t.Run("byte slices", func(t *testing.T) {
+		// comparable wrapper, compared by contents rather than pointer identity
+		type blob struct{ b *[]byte }
+		newBlob := func(s string) blob { b := []byte(s); return blob{&b} }
+		eq := func(a, b blob) bool {
+			if a.b == nil || b.b == nil {
+				return a.b == b.b
+			}
+			return bytes.Equal(*a.b, *b.b)
+		}
+
+		var v Value[blob]
+		requireEqual(t, false, v.CompareAndSwapFunc(newBlob("x"), newBlob("a"), eq))
+		requireEqual(t, true, v.CompareAndSwapFunc(blob{}, newBlob("a"), eq))
+
+		// a different slice with equal contents matches, unlike CompareAndSwap
+		requireEqual(t, false, v.CompareAndSwap(newBlob("a"), newBlob("b")))
+		requireEqual(t, true, v.CompareAndSwapFunc(newBlob("a"), newBlob("b"), eq))
+		requireEqual(t, "b", string(*v.Load().b))
+		requireEqual(t, false, v.CompareAndSwapFunc(newBlob("a"), newBlob("c"), eq))
+	})
+
+	t.Run("ignoring fields", func(t *testing.T) {
+		type record struct {
+			id      int
+			updated time.Time
+		}
+		sameID := func(a, b record) bool { return a.id == b.id }
+
+		var v Value[record]
+		v.Store(record{1, time.Unix(100, 0)})
+		requireEqual(t, true, v.CompareAndSwapFunc(record{id: 1}, record{2, time.Unix(200, 0)}, sameID))
+		requireEqual(t, false, v.CompareAndSwapFunc(record{id: 1}, record{3, time.Unix(300, 0)}, sameID))
+		requireEqual(t, record{2, time.Unix(200, 0)}, v.Load())
+	})
+
+	t.Run("unset compares as zero", func(t *testing.T) {
+		var v Value[int]
+		var gotCur, gotOld int = -1, -1
+		requireEqual(t, false, v.CompareAndSwapFunc(5, 6, func(cur, old int) bool {
+			gotCur, gotOld = cur, old
+			return false
+		}))
+		requireEqual(t, 0, gotCur)
+		requireEqual(t, 5, gotOld)
+		requireEqual(t, false, v.IsSet())
+
+		always := func(int, int) bool { return true }
+		requireEqual(t, true, v.CompareAndSwapFunc(5, 6, always))
+		requireEqual(t, 6, v.Load())
+	})
+}
+
+func TestValue_Accessors(t *testing.T) {
+	var v Value[[2]int]
+	get, set := v.Accessors()
+	requireZero(t, get())
+
+	set([2]int{1, 2})
+	requireEqual(t, [2]int{1, 2}, get())
+	requireEqual(t, [2]int{1, 2}, v.Load())
+
+	v.Store([2]int{3, 4})
+	requireEqual(t, [2]int{3, 4}, get())
+
+	// get only ever returns copies, so a holder of get alone can't write
+	got := get()
+	got[0] = 100
+	requireEqual(t, [2]int{3, 4}, v.Load())
+
+	// closures from separate calls share the same underlying Value
+	get2, set2 := v.Accessors()
+	set2([2]int{5, 6})
+	requireEqual(t, [2]int{5, 6}, get())
+	requireEqual(t, get(), get2())
+	set([2]int{7, 8})
+	requireEqual(t, [2]int{7, 8}, get2())
+}
+
+func TestValue_IsSet(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, false, a.IsSet())
+	a.Load()
+	requireEqual(t, false, a.IsSet())
+	a.Store(0)
+	requireEqual(t, true, a.IsSet())
+
+	var b Value[*int]
+	b.Swap(nil)
+	requireEqual(t, true, b.IsSet())
+
+	var c Value[string]
+	requireEqual(t, false, c.CompareAndSwap("x", "y"))
+	requireEqual(t, false, c.IsSet())
+	requireEqual(t, true, c.CompareAndSwap("", ""))
+	requireEqual(t, true, c.IsSet())
+
+	var d Value[int]
+	d.Update(func(old int) int { return old })
+	requireEqual(t, true, d.IsSet())
+}
+
+func TestValue_LoadPtr(t *testing.T) {
+	var a Value[[1024]byte]
+	requireEqual(t, (*[1024]byte)(nil), a.LoadPtr())
+
+	var big [1024]byte
+	big[0] = 1
+	a.Store(big)
+
+	p := a.LoadPtr()
+	requireEqual(t, byte(1), p[0])
+	requireEqual(t, p, a.LoadPtr())
+
+	big[0] = 2
+	a.Store(big)
+	requireEqual(t, byte(1), p[0]) // unaffected by the store
+	requireEqual(t, byte(2), a.LoadPtr()[0])
+
+	a.Reset()
+	requireEqual(t, (*[1024]byte)(nil), a.LoadPtr())
+
+	t.Run("concurrent", func(t *testing.T) {
+		iters := 10000
+		if testing.Short() {
+			iters = 1000
+		}
+
+		type large struct {
+			seq  int
+			data [128]int
+		}
+
+		fill := func(seq int) *large {
+			l := &large{seq: seq}
+			for i := range l.data {
+				l.data[i] = seq
+			}
+			return l
+		}
+
+		var av Value[large]
+		av.StorePtr(fill(0))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 1; i <= iters; i++ {
+				if i%2 == 0 {
+					av.StorePtr(fill(i))
+				} else {
+					av.Store(*fill(i))
+				}
+				runtime.Gosched()
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			p := av.LoadPtr()
+			snapshot := *p
+			runtime.Gosched()
+			if *p != snapshot {
+				<-done
+				t.Fatalf("value behind pointer changed from seq %d to %d", snapshot.seq, p.seq)
+			}
+		}
+	})
+}
+
+func TestValue_MustLoad(t *testing.T) {
+	mustPanic := func(t *testing.T, want string, fn func()) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			requireEqual[any](t, want, recover())
+		}()
+		fn()
+	}
+
+	var a Value[int]
+	mustPanic(t, "atomicval: MustLoad of unset Value[int]", func() { a.MustLoad() })
+
+	a.Store(0)
+	requireEqual(t, 0, a.MustLoad())
+	a.Store(1)
+	requireEqual(t, 1, a.MustLoad())
+
+	a.Reset()
+	mustPanic(t, "atomicval: MustLoad of unset Value[int]", func() { a.MustLoad() })
+
+	var b Value[io.Writer]
+	mustPanic(t, "atomicval: MustLoad of unset Value[io.Writer]", func() { b.MustLoad() })
+	b.Store(nil)
+	requireZero(t, b.MustLoad())
+
+	var c Value[*ex]
+	mustPanic(t, "atomicval: MustLoad of unset Value[*atomicval.ex]", func() { c.MustLoad() })
+}
+
+func TestValue_StorePtr(t *testing.T) {
+	var a Value[int]
+	n := 1
+	a.StorePtr(&n)
+	requireEqual(t, true, a.IsSet())
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, &n, a.LoadPtr()) // adopted, not copied
+
+	requireEqual(t, 1, a.Swap(2))
+	requireEqual(t, 1, n)
+
+	a.StorePtr(nil)
+	requireEqual(t, false, a.IsSet())
+}
+
+func TestValue_Reset(t *testing.T) {
+	var a Value[int]
+	a.Reset()
+	requireEqual(t, false, a.IsSet())
+
+	a.Store(1)
+	a.Reset()
+	requireEqual(t, false, a.IsSet())
+	requireZero(t, a.Load())
+
+	// unset semantics apply again
+	requireEqual(t, true, a.CompareAndSwap(0, 2))
+	requireEqual(t, 2, a.Load())
+	a.Reset()
+	requireEqual(t, 5, a.UpdateAndGet(func(old int) int { return old + 5 }))
+	a.Reset()
+	actual, loaded := a.LoadOrStore(3)
+	requireEqual(t, 3, actual)
+	requireEqual(t, false, loaded)
+
+	t.Run("concurrent", func(t *testing.T) {
+		data := [][3]uint64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}
+		paralellism := 10 * runtime.GOMAXPROCS(0)
+		iters := 20000
+		if testing.Short() {
+			iters = 2000
+		}
+
+		var av Value[[3]uint64]
+		var wg sync.WaitGroup
+		failChan := make(chan error, paralellism)
+		for g := range paralellism {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range iters {
+					if (g+i)%3 == 0 {
+						av.Reset()
+					} else {
+						av.Store(data[rand.IntN(len(data))])
+					}
+
+					if x := av.Load(); x != [3]uint64{} && !slices.Contains(data, x) {
+						failChan <- fmt.Errorf("value %+v not in test data set: %+v", x, data)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(failChan)
+
+		for err := range failChan {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestValue_Take(t *testing.T) {
+	var a Value[int]
+	val, ok := a.Take()
+	requireZero(t, val)
+	requireEqual(t, false, ok)
+
+	a.Store(0)
+	val, ok = a.Take()
+	requireZero(t, val)
+	requireEqual(t, true, ok)
+	requireEqual(t, false, a.IsSet())
+
+	a.Store(1)
+	a.Store(2)
+	val, ok = a.Take()
+	requireEqual(t, 2, val)
+	requireEqual(t, true, ok)
+	_, ok = a.Take()
+	requireEqual(t, false, ok)
+
+	t.Run("concurrent", func(t *testing.T) {
+		// a producer hands off values one at a time, each claimed by exactly
+		// one of many consumers
+		stores := 10000
+		if testing.Short() {
+			stores = 1000
+		}
+		consumers := 4 * runtime.GOMAXPROCS(0)
+
+		var av Value[int]
+		var claimed atomic.Int64
+		var sum atomic.Int64
+		done := make(chan struct{})
+
+		var wg sync.WaitGroup
+		for range consumers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if val, ok := av.Take(); ok {
+						claimed.Add(1)
+						sum.Add(int64(val))
+						continue
+					}
+
+					select {
+					case <-done:
+						return
+					default:
+						runtime.Gosched()
+					}
+				}
+			}()
+		}
+
+		for i := range stores {
+			for av.IsSet() {
+				runtime.Gosched() // wait for the previous value to be claimed
+			}
+			av.Store(i)
+		}
+		for av.IsSet() {
+			runtime.Gosched()
+		}
+		close(done)
+		wg.Wait()
+
+		requireEqual(t, int64(stores), claimed.Load())
+		requireEqual(t, int64(stores*(stores-1)/2), sum.Load())
+	})
+}
+
+func TestValue_LoadAndReset(t *testing.T) {
+	var a Value[int]
+	requireZero(t, a.LoadAndReset())
+	requireEqual(t, false, a.IsSet())
+
+	a.Store(1)
+	requireEqual(t, 1, a.LoadAndReset())
+	requireEqual(t, false, a.IsSet())
+	requireZero(t, a.LoadAndReset())
+
+	// a stored zero value is drained to unset, too
+	a.Store(0)
+	requireZero(t, a.LoadAndReset())
+	requireEqual(t, false, a.IsSet())
+
+	t.Run("concurrent", func(t *testing.T) {
+		// producers accumulate into the Value while a single drainer
+		// periodically collects the total so far: every increment must be
+		// collected exactly once
+		producers, iters := 4*runtime.GOMAXPROCS(0), 2000
+		if testing.Short() {
+			iters = 200
+		}
+
+		var av Value[int]
+		var wg sync.WaitGroup
+		for range producers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					av.Update(func(old int) int { return old + 1 })
+				}
+			}()
+		}
+
+		done := make(chan struct{})
+		drained := make(chan int)
+		go func() {
+			total := 0
+			for {
+				select {
+				case <-done:
+					drained <- total + av.LoadAndReset()
+					return
+				default:
+					total += av.LoadAndReset()
+					runtime.Gosched()
+				}
+			}
+		}()
+
+		wg.Wait()
+		close(done)
+
+		requireEqual(t, producers*iters, <-drained)
+		requireEqual(t, false, av.IsSet())
+	})
+}
+
+func TestValue_DrainSeq(t *testing.T) {
+	var a Value[int]
+	for val := range a.DrainSeq() {
+		t.Fatalf("unexpected value %d", val)
+	}
+
+	// a store while the loop body runs is yielded next
+	a.Store(1)
+	var got []int
+	for val := range a.DrainSeq() {
+		got = append(got, val)
+		if val < 3 {
+			a.Store(val + 1)
+		}
+	}
+	requireEqual(t, "[1 2 3]", fmt.Sprint(got))
+	requireEqual(t, false, a.IsSet())
+
+	// a stored zero value is yielded
+	a.Store(0)
+	got = nil
+	for val := range a.DrainSeq() {
+		got = append(got, val)
+	}
+	requireEqual(t, "[0]", fmt.Sprint(got))
+
+	// breaking out leaves later values in place
+	a.Store(4)
+	for range a.DrainSeq() {
+		a.Store(5)
+		break
+	}
+	requireEqual(t, 5, a.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		// producers store distinct values; each value is yielded at most once,
+		// and only if it was stored
+		producers, iters := 4, 2000
+		if testing.Short() {
+			iters = 200
+		}
+
+		var av Value[int]
+		var wg sync.WaitGroup
+		for p := range producers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range iters {
+					av.Store(p*iters + i + 1)
+					runtime.Gosched()
+				}
+			}()
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		seen := map[int]bool{}
+		check := func(val int) {
+			if val < 1 || val > producers*iters {
+				t.Fatalf("yielded value %d was never stored", val)
+			}
+			if seen[val] {
+				t.Fatalf("value %d yielded twice", val)
+			}
+			seen[val] = true
+		}
+		for finished := false; !finished; {
+			select {
+			case <-done:
+				finished = true
+			default:
+				runtime.Gosched()
+			}
+			for val := range av.DrainSeq() {
+				check(val)
+			}
+		}
+
+		requireEqual(t, false, av.IsSet())
+		if len(seen) == 0 {
+			t.Fatal("nothing drained")
+		}
+	})
+}
+
+func TestValue_LoadOrStore(t *testing.T) {
+	var a Value[int]
+	actual, loaded := a.LoadOrStore(1)
+	requireEqual(t, 1, actual)
+	requireEqual(t, false, loaded)
+	actual, loaded = a.LoadOrStore(2)
+	requireEqual(t, 1, actual)
+	requireEqual(t, true, loaded)
+	requireEqual(t, 1, a.Load())
+
+	// a stored zero value counts as set
+	var b Value[int]
+	b.Store(0)
+	actual, loaded = b.LoadOrStore(3)
+	requireEqual(t, 0, actual)
+	requireEqual(t, true, loaded)
+
+	var c Value[io.Writer]
+	actual2, loaded := c.LoadOrStore(nil)
+	requireZero(t, actual2)
+	requireEqual(t, false, loaded)
+	actual2, loaded = c.LoadOrStore(io.Discard)
+	requireZero(t, actual2)
+	requireEqual(t, true, loaded)
+
+	t.Run("concurrent", func(t *testing.T) {
+		rounds := 200
+		if testing.Short() {
+			rounds = 20
+		}
+		n := 4 * runtime.GOMAXPROCS(0)
+
+		for range rounds {
+			var av Value[int]
+			var winners atomic.Int32
+			results := make([]int, n)
+
+			var wg sync.WaitGroup
+			for i := range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					actual, loaded := av.LoadOrStore(i + 1)
+					if !loaded {
+						winners.Add(1)
+					}
+					results[i] = actual
+				}()
+			}
+			wg.Wait()
+
+			requireEqual(t, int32(1), winners.Load())
+			for _, got := range results {
+				requireEqual(t, av.Load(), got)
+			}
+		}
+	})
+}
+
+func TestValue_TryStore(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, true, a.TryStore(1))
+	requireEqual(t, false, a.TryStore(2))
+	requireEqual(t, 1, a.Load())
+
+	// a stored zero value counts as set
+	var b Value[int]
+	b.Store(0)
+	requireEqual(t, false, b.TryStore(3))
+	requireEqual(t, 0, b.Load())
+
+	// and after a reset, the Value is unset again
+	b.Reset()
+	requireEqual(t, true, b.TryStore(3))
+	requireEqual(t, 3, b.Load())
+
+	var c Value[io.Writer]
+	requireEqual(t, true, c.TryStore(nil))
+	requireEqual(t, true, c.IsSet())
+	requireEqual(t, false, c.TryStore(io.Discard))
+	requireZero(t, c.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		rounds := 200
+		if testing.Short() {
+			rounds = 20
+		}
+		n := 4 * runtime.GOMAXPROCS(0)
+
+		for range rounds {
+			var av Value[int]
+			var winners atomic.Int32
+			winner := make([]bool, n)
+
+			var wg sync.WaitGroup
+			for i := range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if av.TryStore(i + 1) {
+						winners.Add(1)
+						winner[i] = true
+					}
+				}()
+			}
+			wg.Wait()
+
+			requireEqual(t, int32(1), winners.Load())
+			requireEqual(t, true, winner[av.Load()-1])
+		}
+	})
+}
+
+func TestValue_StoreIfChanged(t *testing.T) {
+	var a Value[int]
+	var changes int
+	a.SetOnChange(func(_, _ int) { changes++ })
+
+	// unset to zero is a change
+	requireEqual(t, true, a.StoreIfChanged(0))
+	requireEqual(t, true, a.IsSet())
+	requireEqual(t, false, a.StoreIfChanged(0))
+	requireEqual(t, true, a.StoreIfChanged(1))
+	requireEqual(t, false, a.StoreIfChanged(1))
+	requireEqual(t, 1, a.Load())
+	requireEqual(t, 2, changes)
+
+	// an unchanged value doesn't allocate
+	requireEqual(t, 0.0, testing.AllocsPerRun(100, func() { a.StoreIfChanged(1) }))
+
+	// incomparable dynamic types always count as changed
+	var b Value[any]
+	requireEqual(t, true, b.StoreIfChanged([]int{1}))
+	requireEqual(t, true, b.StoreIfChanged([]int{1}))
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		// goroutines store the same two values in turn: every reported write
+		// must have been a real change, as counted by the notification
+		av := New(0) // so that the notified old value is never a stand-in
+		var notified atomic.Int64
+		av.SetOnChange(func(old, new int) {
+			if old == new {
+				t.Errorf("unchanged value %d stored", new)
+			}
+			notified.Add(1)
+		})
+
+		var stores atomic.Int64
+		var wg sync.WaitGroup
+		for g := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range iters {
+					if av.StoreIfChanged((g + i) % 2) {
+						stores.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, notified.Load(), stores.Load())
+	})
+}
+
+func TestValue_Update(t *testing.T) {
+	var a Value[int]
+	a.Update(func(old int) int {
+		requireZero(t, old)
+		return old + 1
+	})
+	requireEqual(t, 1, a.Load())
+	a.Update(func(old int) int { return old * 10 })
+	requireEqual(t, 10, a.Load())
+
+	var b Value[io.Writer]
+	b.Update(func(old io.Writer) io.Writer {
+		requireZero(t, old)
+		return io.Discard
+	})
+	requireEqual(t, io.Discard, b.Load())
+
+	// a single box is allocated, including from the unset state
+	var c Value[ex]
+	allocs := testing.AllocsPerRun(100, func() {
+		c.Update(func(old ex) ex { old.a++; return old })
+	})
+	requireEqual(t, 1.0, allocs)
+
+	t.Run("concurrent", func(t *testing.T) {
+		n := 10000
+		if testing.Short() {
+			n = 1000
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+
+		var av Value[int]
+		var calls atomic.Int64
+		for i := range n {
+			go func(i int) {
+				defer wg.Done()
+				av.Update(func(old int) int {
+					calls.Add(1)
+					runtime.Gosched() // encourage contention
+					return old + i
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		// 0 + 1 + ... + n-1
+		requireEqual(t, (n-1)*n/2, av.Load())
+		if calls.Load() < int64(n) {
+			t.Fatalf("fn called %d times for %d updates", calls.Load(), n)
+		}
+	})
+}
+
+func TestValue_Modify(t *testing.T) {
+	var a Value[ex]
+	a.Modify(func(val *ex) {
+		requireZero(t, *val)
+		val.a = 1
+		val.b = "x"
+	})
+	requireEqual(t, ex{a: 1, b: "x"}, a.Load())
+
+	// the live value is never modified
+	p := a.LoadPtr()
+	a.Modify(func(val *ex) {
+		if val == p {
+			t.Fatal("mutate was passed the current value")
+		}
+		val.a++
+	})
+	requireEqual(t, ex{a: 1, b: "x"}, *p)
+	requireEqual(t, ex{a: 2, b: "x"}, a.Load())
+
+	// a single box is allocated
+	allocs := testing.AllocsPerRun(100, func() {
+		a.Modify(func(val *ex) { val.a++ })
+	})
+	requireEqual(t, 1.0, allocs)
+
+	t.Run("concurrent", func(t *testing.T) {
+		n := 4 * runtime.GOMAXPROCS(0)
+		iters := 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		// fields which must always be equal
+		type pair struct{ x, y int }
+		var av Value[pair]
+
+		done := make(chan struct{})
+		var readers sync.WaitGroup
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if p := av.Load(); p.x != p.y {
+					t.Errorf("observed intermediate state %+v", p)
+					return
+				}
+				runtime.Gosched()
+			}
+		}()
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for range n {
+			go func() {
+				defer wg.Done()
+				for range iters {
+					av.Modify(func(p *pair) {
+						p.x++
+						runtime.Gosched() // encourage contention
+						p.y++
+					})
+				}
+			}()
+		}
+		wg.Wait()
+		close(done)
+		readers.Wait()
+
+		requireEqual(t, pair{n * iters, n * iters}, av.Load())
+	})
+}
+
+func TestValue_StoreFunc(t *testing.T) {
+	var a Value[string]
+	a.StoreFunc(func() string { return "a" })
+	requireEqual(t, "a", a.Load())
+
+	// a mutation while fn runs makes it run again
+	calls := 0
+	a.StoreFunc(func() string {
+		calls++
+		if calls == 1 {
+			a.Store("intervening")
+		}
+		return fmt.Sprint(calls)
+	})
+	requireEqual(t, 2, calls)
+	requireEqual(t, "2", a.Load())
+
+	t.Run("concurrent", func(t *testing.T) {
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
+		if testing.Short() {
+			iters = 100
+		}
+
+		// fn publishes a counter's value as of the time it ran: since a result
+		// computed before another goroutine's store is never installed after
+		// it, the final value reflects the final count
+		var counter atomic.Int64
+		var av Value[int64]
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iters {
+					counter.Add(1)
+					av.StoreFunc(counter.Load)
+				}
+			}()
+		}
+		wg.Wait()
+
+		requireEqual(t, int64(n*iters), av.Load())
+	})
+}
+
+func TestValue_UpdateContext(t *testing.T) {
+	var a Value[int]
+	got, err := a.UpdateContext(context.Background(), func(old int) int { return old + 1 })
+	requireZero(t, err)
+	requireEqual(t, 1, got)
+	requireEqual(t, 1, a.Load())
+
+	// a done context stops before calling fn
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err = a.UpdateContext(ctx, func(int) int {
+		t.Fatal("fn called after cancellation")
+		return 0
+	})
+	requireEqual(t, context.Canceled, err)
+	requireZero(t, got)
+	requireEqual(t, 1, a.Load())
+
+	t.Run("cancelled mid-contention", func(t *testing.T) {
+		// a competing writer modifies the Value during every attempt, until
+		// the context is cancelled
+		av := New(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		calls := 0
+		got, err := av.UpdateContext(ctx, func(old int) int {
+			calls++
+			if calls == 3 {
+				cancel()
+			}
+			av.Store(-calls) // the competing write
+			return old + 100
+		})
+		requireEqual(t, context.Canceled, err)
+		requireEqual(t, 3, calls)
+		requireEqual(t, -2+100, got) // the last candidate, from the last old value
+		requireEqual(t, -3, av.Load())
+	})
+
 	t.Run("concurrent", func(t *testing.T) {
-		// generate a slice of random data -- lengths/sizes are mostly arbitrary
-		randArr := func() [3]uint64 {
-			return [3]uint64{rand.Uint64(), rand.Uint64(), rand.Uint64()}
-		}
-		data := [][3]uint64{randArr(), randArr(), randArr(), randArr()}
-
-		paralellism := 100 * runtime.GOMAXPROCS(0)
-		iters := 50000
+		n, iters := 4*runtime.GOMAXPROCS(0), 1000
 		if testing.Short() {
-			iters = 10000
+			iters = 100
 		}
 
-		wg := sync.WaitGroup{}
-		wg.Add(paralellism)
-		failChan := make(chan error)
-		go func() {
-			wg.Wait()
-			close(failChan)
-		}()
-
-		var av Value[[3]uint64]
-		for range paralellism {
+		var av Value[int]
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
 			go func() {
 				defer wg.Done()
 				for range iters {
-					x := data[rand.IntN(len(data))]
-					av.Store(x)
-					x = av.Load()
-
-					if !slices.Contains(data, x) {
-						failChan <- fmt.Errorf("value %+v not in test data set: %+v", x, data)
+					if _, err := av.UpdateContext(context.Background(), func(old int) int { return old + 1 }); err != nil {
+						t.Error(err)
+						return
 					}
 				}
 			}()
 		}
+		wg.Wait()
 
-		for err := range failChan {
-			t.Fatal(err)
-		}
+		requireEqual(t, n*iters, av.Load())
 	})
 }
 
-func TestValue_Swap(t *testing.T) {
-	var a Value[uint64]
-	requireEqual(t, uint64(0), a.Swap(1))
-	requireEqual(t, uint64(1), a.Swap(math.MaxUint64))
-	requireEqual(t, uint64(math.MaxUint64), a.Swap(0))
+func TestValue_GetAndUpdate(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, 0, a.GetAndUpdate(func(old int) int { return old + 1 }))
+	requireEqual(t, 1, a.GetAndUpdate(func(old int) int { return old + 1 }))
+	requireEqual(t, 2, a.Load())
 
-	var b Value[*int]
-	ptrA := new(int)
-	requireZero(t, b.Swap(ptrA))
-	requireEqual(t, ptrA, b.Swap(new(int)))
-	requireNotZero(t, b.Swap(nil))
-	requireZero(t, b.Swap(ptrA))
+	requireEqual(t, 12, a.UpdateAndGet(func(old int) int { return old + 10 }))
+	requireEqual(t, 12, a.Load())
 
-	var c Value[io.Writer]
-	requireZero(t, c.Swap(io.Discard))
-	requireEqual(t, io.Discard, c.Swap(io.Discard))
-	buf := bytes.NewBuffer(nil)
-	requireEqual(t, io.Discard, c.Swap(buf))
-	requireEqual[io.Writer](t, buf, c.Swap(nil))
+	var b Value[*int]
+	p := new(int)
+	requireEqual(t, p, b.UpdateAndGet(func(old *int) *int {
+		requireZero(t, old)
+		return p
+	}))
+	requireEqual(t, p, b.GetAndUpdate(func(*int) *int { return nil }))
+	requireZero(t, b.Load())
 
-	// based on stdlib test
 	t.Run("concurrent", func(t *testing.T) {
-		// calculate the sum of integers 1...N-1 by addition, splitting the range
-		// into chunks per goroutine, then compare the result against a known
-		// formula.
-		var chunks, chunkSize uint64 = 1000, 100000
+		// every increment must return a distinct old (and new) value, together
+		// covering the full range
+		n, iters := 4*runtime.GOMAXPROCS(0), 2000
 		if testing.Short() {
-			chunks, chunkSize = 1000, 1000
+			iters = 200
 		}
-		N := chunkSize * chunks
-
-		// 1 + 2 + 3 ... N-1 = N * (N - 1) / 2
-		expected := (N - 1) * N / 2
 
+		var av Value[int]
+		olds := make([][]int, n)
+		news := make([][]int, n)
 		var wg sync.WaitGroup
-		var sum atomic.Uint64
-		var av Value[uint64]
-		for start := uint64(0); start < N; start += chunkSize {
+		for g := range n {
 			wg.Add(1)
-			go func(start uint64) {
-				var localSum uint64 // avoid [atomic.Uint64.Add] bottleneck
-				for x := range chunkSize {
-					localSum += av.Swap(start + x)
+			go func() {
+				defer wg.Done()
+				for i := range iters {
+					inc := func(old int) int { return old + 1 }
+					if i%2 == 0 {
+						olds[g] = append(olds[g], av.GetAndUpdate(inc))
+					} else {
+						news[g] = append(news[g], av.UpdateAndGet(inc)-1)
+					}
 				}
-				sum.Add(localSum)
-				wg.Done()
-			}(uint64(start))
+			}()
 		}
 		wg.Wait()
 
-		// the value swapped in by the last [Value.Swap] won't have been returned by a
-		// subsequent call, so add it here from [Value.Load]
-		sum.Add(av.Load())
-		requireEqual(t, expected, sum.Load())
+		all := slices.Concat(slices.Concat(olds...), slices.Concat(news...))
+		slices.Sort(all)
+		requireEqual(t, n*iters, len(all))
+		for i, old := range all {
+			requireEqual(t, i, old)
+		}
+		requireEqual(t, n*iters, av.Load())
 	})
 }
 
-func TestValue_CompareAndSwap(t *testing.T) {
+func TestValue_Clone(t *testing.T) {
 	var a Value[int]
-	requireEqual(t, true, a.CompareAndSwap(0, 1))
-	requireEqual(t, true, a.CompareAndSwap(1, 2))
-	requireEqual(t, false, a.CompareAndSwap(3, 4))
+	c := a.Clone()
+	requireEqual(t, false, c.IsSet())
 
-	var b Value[io.Writer]
-	requireEqual(t, true, b.CompareAndSwap(nil, io.Discard))
-	requireEqual(t, false, b.CompareAndSwap(nil, io.Discard))
-	requireEqual(t, true, b.CompareAndSwap(io.Discard, nil))
-	requireEqual(t, false, b.CompareAndSwap(io.Discard, nil))
+	a.Store(1)
+	requireEqual(t, false, c.IsSet())
+
+	a.Store(0)
+	c = a.Clone()
+	requireEqual(t, true, c.IsSet())
+	requireEqual(t, 0, c.Load())
+	requireNotEqual(t, a.boxPtr(), c.boxPtr())
+
+	a.Store(2)
+	requireEqual(t, 0, c.Load())
+
+	c.Store(3)
+	requireEqual(t, 2, a.Load())
+
+	a.Reset()
+	requireEqual(t, true, c.IsSet())
+	requireEqual(t, 3, c.Load())
+
+	a.Store(4)
+	c = a.Clone()
+	requireEqual(t, true, c.CompareAndSwap(4, 5))
+	requireEqual(t, 4, a.Load())
+}
+
+func TestValue_Equal(t *testing.T) {
+	var a, b Value[int]
+	requireEqual(t, true, a.Equal(&b))
+	requireEqual(t, true, a.Equal(&a))
+
+	a.Store(0)
+	requireEqual(t, false, a.Equal(&b))
+	requireEqual(t, false, b.Equal(&a))
+
+	b.Store(0)
+	requireEqual(t, true, a.Equal(&b))
+
+	b.Store(1)
+	requireEqual(t, false, a.Equal(&b))
+
+	a.Store(1)
+	requireEqual(t, true, a.Equal(&b))
+
+	var x, y Value[any]
+	x.Store([]int{1})
+	y.Store([]int{1})
+	requireEqual(t, false, x.Equal(&y))
+
+	x.Store(io.Writer(nil))
+	y.Store(nil)
+	requireEqual(t, true, x.Equal(&y))
+}
+
+func TestCompareAndSwapDiff(t *testing.T) {
+	changedFields := func(expected, actual ex) (fields []string) {
+		if expected.a != actual.a {
+			fields = append(fields, "a")
+		}
+		if expected.b != actual.b {
+			fields = append(fields, "b")
+		}
+		if expected.c != actual.c {
+			fields = append(fields, "c")
+		}
+		return fields
+	}
+
+	var v Value[ex]
+
+	// unset compares as the zero value
+	d, swapped := CompareAndSwapDiff(&v, ex{a: 1}, ex{a: 2}, changedFields)
+	requireEqual(t, false, swapped)
+	requireEqual(t, "a", strings.Join(d, ","))
+
+	d, swapped = CompareAndSwapDiff(&v, ex{}, ex{1, "1", 1i}, changedFields)
+	requireEqual(t, true, swapped)
+	requireEqual(t, 0, len(d))
+	requireEqual(t, ex{1, "1", 1i}, v.Load())
+
+	// "someone else" changed b and c
+	v.Store(ex{1, "2", 2i})
+	var witnessed ex
+	d, swapped = CompareAndSwapDiff(&v, ex{1, "1", 1i}, ex{3, "3", 3i}, func(expected, actual ex) []string {
+		witnessed = actual
+		return changedFields(expected, actual)
+	})
+	requireEqual(t, false, swapped)
+	requireEqual(t, "b,c", strings.Join(d, ","))
+	requireEqual(t, ex{1, "2", 2i}, witnessed)
+	requireEqual(t, ex{1, "2", 2i}, v.Load())
 
 	t.Run("concurrent", func(t *testing.T) {
+		// like the CompareAndSwap test, but failed attempts must always
+		// witness a value other than the expected one
 		n := 10000
 		if testing.Short() {
 			n = 1000
@@ -214,22 +1864,90 @@ func TestValue_CompareAndSwap(t *testing.T) {
 		wg.Add(n)
 
 		var av Value[int]
-
-		// "i" counts down to maximize contention
+		var badDiffs atomic.Int64
 		for i := n - 1; i >= 0; i-- {
 			go func(i int) {
-				// each goroutine must succeed exactly once (no values are "missed")
-				for !av.CompareAndSwap(i, i+1) {
+				defer wg.Done()
+				for {
+					_, swapped := CompareAndSwapDiff(&av, i, i+1, func(expected, actual int) int {
+						if expected == actual {
+							badDiffs.Add(1)
+						}
+						return actual
+					})
+					if swapped {
+						return
+					}
 					runtime.Gosched()
 				}
-				wg.Done()
 			}(i)
 		}
 
 		wg.Wait()
+		requireEqual(t, int64(0), badDiffs.Load())
+		requireEqual(t, n, av.Load())
 	})
 }
 
+func TestStoreAny(t *testing.T) {
+	var a Value[int]
+	requireEqual(t, nil, StoreAny(&a, 1))
+	requireEqual(t, 1, a.Load())
+
+	err := StoreAny(&a, "2")
+	requireNotZero(t, err)
+	requireEqual(t, "atomicval: cannot store string in Value[int]", err.Error())
+	requireNotZero(t, StoreAny(&a, int64(2)))
+	requireNotZero(t, StoreAny(&a, nil))
+	requireEqual(t, 1, a.Load())
+
+	var b Value[io.Writer]
+	requireEqual(t, nil, StoreAny(&b, io.Discard))
+	requireEqual(t, io.Discard, b.Load())
+	requireNotZero(t, StoreAny(&b, 1))
+	requireEqual(t, io.Discard, b.Load())
+
+	requireEqual(t, nil, StoreAny(&b, nil))
+	requireZero(t, b.Load())
+
+	var c Value[*int]
+	err = StoreAny(&c, nil)
+	requireNotZero(t, err)
+	requireEqual(t, "atomicval: cannot store <nil> in Value[*int]", err.Error())
+	requireEqual(t, nil, StoreAny(&c, (*int)(nil)))
+}
+
+func TestValue_boxIdentity(t *testing.T) {
+	var v Value[ex]
+	requireZero(t, v.boxPtr())
+
+	// every successful mutation publishes a fresh box, even for equal values
+	v.Store(ex{a: 1})
+	first := v.boxPtr()
+	requireNotZero(t, first)
+	v.Store(ex{a: 1})
+	second := v.boxPtr()
+	requireNotEqual(t, first, second)
+
+	requireEqual(t, ex{a: 1}, v.Swap(ex{a: 2}))
+	third := v.boxPtr()
+	requireNotEqual(t, second, third)
+
+	// reads and failed CompareAndSwaps leave the box alone
+	v.Load()
+	requireEqual(t, false, v.CompareAndSwap(ex{a: 1}, ex{a: 3}))
+	requireEqual(t, third, v.boxPtr())
+
+	requireEqual(t, true, v.CompareAndSwap(ex{a: 2}, ex{a: 3}))
+	requireNotEqual(t, third, v.boxPtr())
+}
+
+// boxPtr exposes the current box for tests which care about box identity
+// (allocation/reuse) rather than contents.
+func (v *Value[T]) boxPtr() unsafe.Pointer {
+	return atomic.LoadPointer(&v.v)
+}
+
 // avoid dependency on testify etc., since we have simple needs here
 
 func requireZero[T comparable](t *testing.T, v T) {
@@ -520,6 +2238,43 @@ func BenchmarkCompareAndSwap(b *testing.B) {
 }
 
 // benchmark CompareAndSwap in the case where we retry until it succeeds
+func BenchmarkCompareAndSwap_large(b *testing.B) {
+	type tt [256]byte
+	var x, y, z tt
+	y[len(y)-1] = 1
+	z[0] = 1
+
+	b.Run("mismatch/Value", func(b *testing.B) {
+		av := New(x)
+		for range b.N {
+			runtime.KeepAlive(av.CompareAndSwap(z, y))
+		}
+	})
+
+	b.Run("mismatch/Value_ptr", func(b *testing.B) {
+		av := New(x)
+		for range b.N {
+			runtime.KeepAlive(av.CompareAndSwapPtr(&z, y))
+		}
+	})
+
+	b.Run("swap/Value", func(b *testing.B) {
+		av := New(x)
+		for range b.N {
+			runtime.KeepAlive(av.CompareAndSwap(x, y))
+			runtime.KeepAlive(av.CompareAndSwap(y, x))
+		}
+	})
+
+	b.Run("swap/Value_ptr", func(b *testing.B) {
+		av := New(x)
+		for range b.N {
+			runtime.KeepAlive(av.CompareAndSwapPtr(&x, y))
+			runtime.KeepAlive(av.CompareAndSwapPtr(&y, x))
+		}
+	})
+}
+
 func BenchmarkCompareAndSwap_retries(b *testing.B) {
 	const paralellism = 100
 
@@ -546,6 +2301,25 @@ func BenchmarkCompareAndSwap_retries(b *testing.B) {
 		})
 	})
 
+	b.Run("Value_weak", func(b *testing.B) {
+		var av Value[tt]
+		av.Store(x)
+
+		b.SetParallelism(paralellism)
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				for !av.CompareAndSwapWeak(x, y) {
+					runtime.Gosched()
+				}
+				for !av.CompareAndSwapWeak(y, x) {
+					runtime.Gosched()
+				}
+			}
+		})
+	})
+
 	b.Run("stdlib_baseline", func(b *testing.B) {
 		var av atomic.Value
 		av.Store(x)
@@ -604,6 +2378,52 @@ func BenchmarkCompareAndSwap_retries(b *testing.B) {
 	})
 }
 
+// BenchmarkCompareAndSwap_fromUnset measures the common first operation on a
+// Value: a CompareAndSwap from the zero value, on a Value which is still unset.
+// In the contended case, several goroutines race for each Value, and all but
+// one of them lose.
+func BenchmarkCompareAndSwap_fromUnset(b *testing.B) {
+	const racers = 4
+
+	type tt [32]uint8
+	var x, y tt
+	y[len(y)-1] = 1
+
+	b.Run("Value", func(b *testing.B) {
+		avs := make([]Value[tt], b.N)
+
+		runtime.GC()
+		b.ResetTimer()
+		for i := range avs {
+			runtime.KeepAlive(avs[i].CompareAndSwap(x, y))
+		}
+	})
+
+	b.Run("Value_contended", func(b *testing.B) {
+		avs := make([]Value[tt], b.N/racers+1)
+		var next atomic.Int64
+
+		runtime.GC()
+		b.ResetTimer()
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				i := next.Add(1) - 1
+				runtime.KeepAlive(avs[i/racers].CompareAndSwap(x, y))
+			}
+		})
+	})
+
+	b.Run("stdlib_baseline", func(b *testing.B) {
+		avs := make([]atomic.Value, b.N)
+
+		runtime.GC()
+		b.ResetTimer()
+		for i := range avs {
+			runtime.KeepAlive(avs[i].CompareAndSwap(nil, y))
+		}
+	})
+}
+
 // benchmark mixed methods being called concurrently -- exact mix is entirely arbitrary
 func BenchmarkMedley(b *testing.B) {
 	const paralellism = 100
@@ -708,3 +2528,209 @@ func BenchmarkMedley(b *testing.B) {
 		})
 	})
 }
+
+func writerPtr(w io.Writer) *io.Writer { return &w }
+
+// benchmark the interface-typed path, where the value is two words (type, data)
+// and may hold mixed dynamic types. "atomicPointer" is the hand-written
+// equivalent of [Value] using [atomic.Pointer]. Note that stdlib avoids
+// allocating on Store only because all stored values share one dynamic type.
+func BenchmarkInterface(b *testing.B) {
+	const paralellism = 100
+
+	var x, y io.Writer = fakeWriter{}, io.Discard
+
+	b.Run("Load", func(b *testing.B) {
+		b.Run("Value", func(b *testing.B) {
+			var av Value[io.Writer]
+			av.Store(x)
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(av.Load())
+				}
+			})
+		})
+
+		b.Run("stdlib_baseline", func(b *testing.B) {
+			var av atomic.Value
+			av.Store(x)
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(av.Load().(io.Writer))
+				}
+			})
+		})
+
+		b.Run("atomicPointer", func(b *testing.B) {
+			var av atomic.Pointer[io.Writer]
+			av.Store(&x)
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					var w io.Writer
+					if ptr := av.Load(); ptr != nil {
+						w = *ptr
+					}
+					runtime.KeepAlive(w)
+				}
+			})
+		})
+	})
+
+	b.Run("Store", func(b *testing.B) {
+		b.Run("Value", func(b *testing.B) {
+			var av Value[io.Writer]
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					av.Store(x)
+					av.Store(y)
+				}
+			})
+		})
+
+		// note: stdlib would panic on mixed dynamic types, so only x is stored
+		b.Run("stdlib_baseline", func(b *testing.B) {
+			var av atomic.Value
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					av.Store(x)
+					av.Store(x)
+				}
+			})
+		})
+
+		b.Run("atomicPointer", func(b *testing.B) {
+			var av atomic.Pointer[io.Writer]
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					av.Store(writerPtr(x))
+					av.Store(writerPtr(y))
+				}
+			})
+		})
+	})
+
+	b.Run("Swap", func(b *testing.B) {
+		b.Run("Value", func(b *testing.B) {
+			var av Value[io.Writer]
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(av.Swap(x))
+					runtime.KeepAlive(av.Swap(y))
+				}
+			})
+		})
+
+		b.Run("stdlib_baseline", func(b *testing.B) {
+			var av atomic.Value
+			av.Store(x) // avoid panic in type conversion below
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(av.Swap(x).(io.Writer))
+					runtime.KeepAlive(av.Swap(x).(io.Writer))
+				}
+			})
+		})
+
+		b.Run("atomicPointer", func(b *testing.B) {
+			var av atomic.Pointer[io.Writer]
+			av.Store(&x) // avoid nil dereference below
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(*av.Swap(writerPtr(x)))
+					runtime.KeepAlive(*av.Swap(writerPtr(y)))
+				}
+			})
+		})
+	})
+
+	b.Run("CompareAndSwap", func(b *testing.B) {
+		b.Run("Value", func(b *testing.B) {
+			var av Value[io.Writer]
+			av.Store(x)
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(av.CompareAndSwap(x, y))
+					runtime.KeepAlive(av.CompareAndSwap(y, x))
+				}
+			})
+		})
+
+		// note: stdlib would panic on mixed dynamic types, so use different
+		// values of one type instead
+		b.Run("stdlib_baseline", func(b *testing.B) {
+			var x, y io.Writer = &bytes.Buffer{}, &bytes.Buffer{}
+			var av atomic.Value
+			av.Store(x)
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(av.CompareAndSwap(x, y))
+					runtime.KeepAlive(av.CompareAndSwap(y, x))
+				}
+			})
+		})
+
+		b.Run("atomicPointer", func(b *testing.B) {
+			var av atomic.Pointer[io.Writer]
+			av.Store(&x)
+
+			cas := func(old, new io.Writer) bool {
+				cur := av.Load()
+				return *cur == old && av.CompareAndSwap(cur, writerPtr(new))
+			}
+
+			b.SetParallelism(paralellism)
+			runtime.GC()
+			b.ResetTimer()
+			b.RunParallel(func(p *testing.PB) {
+				for p.Next() {
+					runtime.KeepAlive(cas(x, y))
+					runtime.KeepAlive(cas(y, x))
+				}
+			})
+		})
+	})
+}
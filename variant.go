@@ -0,0 +1,67 @@
+package atomicval
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// tagged is the in-box representation of a [Variant].
+type tagged[T comparable] struct {
+	tag int
+	val T
+}
+
+// Variant is an atomic store for a discriminated union: a value of type T
+// paired with an integer tag identifying which variant it represents. The tag
+// and value are always stored, loaded and swapped together. An unset Variant
+// has tag 0 and the zero value of T.
+//
+// The zero Variant is ready for use. Must not be copied after first use.
+type Variant[T comparable] struct {
+	v Value[tagged[T]]
+}
+
+// Load returns the tag and value set by the most recent mutation.
+func (v *Variant[T]) Load() (tag int, val T) {
+	t := v.v.Load()
+	return t.tag, t.val
+}
+
+// Store sets the tag and value.
+func (v *Variant[T]) Store(tag int, val T) {
+	v.v.Store(tagged[T]{tag, val})
+}
+
+// CompareAndSwap replaces the current tag and value with newTag and new if
+// both currently equal oldTag and old, with the semantics of
+// [Value.CompareAndSwap].
+func (v *Variant[T]) CompareAndSwap(oldTag int, old T, newTag int, new T) (swapped bool) {
+	return v.v.CompareAndSwap(tagged[T]{oldTag, old}, tagged[T]{newTag, new})
+}
+
+// CompareTagAndSwap replaces the current tag and value with newTag and new if
+// the current tag is oldTag, regardless of the current value. This is the
+// usual way to transition between variants.
+func (v *Variant[T]) CompareTagAndSwap(oldTag int, new T, newTag int) (swapped bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		dp := atomic.LoadPointer(&v.v.v)
+		tag := 0
+		if dp != nil {
+			tag = (*tagged[T])(dp).tag
+		}
+
+		if tag != oldTag {
+			return false
+		}
+
+		if box == nil {
+			box = unsafe.Pointer(&[1]tagged[T]{{newTag, new}})
+		}
+
+		// retry if only the value changed in the meantime
+		if v.v.compareAndSwapBox(dp, box) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package atomicval
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	stateIdle = iota
+	stateRunning
+	stateFailed
+)
+
+func TestVariant(t *testing.T) {
+	var v Variant[string]
+	tag, val := v.Load()
+	requireEqual(t, stateIdle, tag)
+	requireZero(t, val)
+
+	// only fires from the expected tag
+	requireEqual(t, false, v.CompareTagAndSwap(stateRunning, "job-1", stateFailed))
+	requireEqual(t, true, v.CompareTagAndSwap(stateIdle, "job-1", stateRunning))
+	tag, val = v.Load()
+	requireEqual(t, stateRunning, tag)
+	requireEqual(t, "job-1", val)
+
+	requireEqual(t, false, v.CompareTagAndSwap(stateIdle, "job-2", stateRunning))
+	requireEqual(t, true, v.CompareTagAndSwap(stateRunning, "timeout", stateFailed))
+	tag, val = v.Load()
+	requireEqual(t, stateFailed, tag)
+	requireEqual(t, "timeout", val)
+
+	// full comparison on both tag and value
+	requireEqual(t, false, v.CompareAndSwap(stateFailed, "other", stateIdle, ""))
+	requireEqual(t, false, v.CompareAndSwap(stateRunning, "timeout", stateIdle, ""))
+	requireEqual(t, true, v.CompareAndSwap(stateFailed, "timeout", stateIdle, ""))
+
+	v.Store(stateRunning, "job-3")
+	tag, val = v.Load()
+	requireEqual(t, stateRunning, tag)
+	requireEqual(t, "job-3", val)
+
+	t.Run("concurrent", func(t *testing.T) {
+		// goroutines race to move idle -> running; exactly one wins each round,
+		// and the tag always matches the value written with it
+		rounds := 1000
+		if testing.Short() {
+			rounds = 100
+		}
+		goroutines := 4 * runtime.GOMAXPROCS(0)
+
+		var v Variant[int]
+		for range rounds {
+			var wins atomic.Int32
+			var wg sync.WaitGroup
+			for g := range goroutines {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if v.CompareTagAndSwap(stateIdle, g, stateRunning) {
+						wins.Add(1)
+					}
+					if tag, val := v.Load(); tag == stateRunning && (val < 0 || val >= goroutines) {
+						t.Errorf("running with unexpected value %d", val)
+					}
+				}()
+			}
+			wg.Wait()
+
+			requireEqual(t, int32(1), wins.Load())
+			tag, _ := v.Load()
+			requireEqual(t, stateRunning, tag)
+			v.Store(stateIdle, -1)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package atomicval
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestValue_vetCopylocks runs go vet's copylocks check over testdata, which
+// copies Values in various ways, and checks that exactly the lines marked with
+// a "// want" comment are reported.
+func TestValue_vetCopylocks(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available:", err)
+	}
+
+	const dir = "testdata/copylocks"
+	src := filepath.Join(dir, "copylocks.go")
+
+	want := map[string]bool{}
+	f, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for line, sc := 1, bufio.NewScanner(f); sc.Scan(); line++ {
+		if strings.HasSuffix(sc.Text(), "// want") {
+			want[fmt.Sprintf("%s:%d", src, line)] = true
+		}
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(goTool, "vet", "-copylocks", "./"+dir)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err == nil {
+		t.Fatal("go vet reported nothing")
+	}
+
+	got := map[string]bool{}
+	report := regexp.MustCompile(`^(\S+\.go:\d+):\d+: .*lock`)
+	for _, line := range strings.Split(out.String(), "\n") {
+		if m := report.FindStringSubmatch(line); m != nil {
+			got[filepath.ToSlash(m[1])] = true
+		}
+	}
+
+	for pos := range want {
+		if !got[pos] {
+			t.Errorf("%s: copy not reported", pos)
+		}
+	}
+	for pos := range got {
+		if !want[pos] {
+			t.Errorf("%s: unexpected report", pos)
+		}
+	}
+	if t.Failed() {
+		t.Logf("go vet output:\n%s", out.String())
+	}
+}
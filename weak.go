@@ -0,0 +1,70 @@
+//go:build go1.24
+
+package atomicval
+
+import (
+	"sync/atomic"
+	"unsafe"
+	"weak"
+)
+
+// WeakValue is an atomic slot holding a weak reference to a *T, for caches
+// whose contents should not be kept alive by the cache alone. Once nothing
+// else references the stored object, the garbage collector may reclaim it, and
+// the WeakValue then holds nil.
+//
+// Reclamation can happen at any time after the last strong reference is
+// dropped, including between a Store and the next Load: a Load may return nil
+// even though no mutation has occurred, so callers must always be prepared to
+// recompute the value. Conversely, the pointer returned by Load is a strong
+// reference which keeps the object alive for as long as the caller holds it.
+//
+// WeakValue is only available when building with Go 1.24 or later.
+//
+// The zero WeakValue is ready for use, and holds nil. Must not be copied after
+// first use.
+type WeakValue[T any] struct {
+	v Value[weak.Pointer[T]]
+}
+
+// Load returns the pointer set by the most recent mutation, or nil if none has
+// been set or the object it points to has been reclaimed.
+func (w *WeakValue[T]) Load() *T {
+	return w.v.Load().Value()
+}
+
+// Store sets the referenced object to val, which may be nil. The WeakValue
+// does not keep val alive.
+func (w *WeakValue[T]) Store(val *T) {
+	w.v.Store(weak.Make(val))
+}
+
+// Swap stores new and returns the previous pointer, or nil if none had been
+// set or it has been reclaimed.
+func (w *WeakValue[T]) Swap(new *T) (old *T) {
+	return w.v.Swap(weak.Make(new)).Value()
+}
+
+// CompareAndSwap stores new if the currently referenced pointer is old,
+// reporting whether it did so. A reclaimed object compares as nil, so
+// CompareAndSwap(nil, new) succeeds once the current object has been
+// collected.
+func (w *WeakValue[T]) CompareAndSwap(old, new *T) (swapped bool) {
+	var box unsafe.Pointer // allocated once, only when needed
+	for {
+		dp := atomic.LoadPointer(&w.v.v)
+		if boxed[weak.Pointer[T]](dp).Value() != old {
+			return false
+		}
+
+		if box == nil {
+			box = unsafe.Pointer(&[1]weak.Pointer[T]{weak.Make(new)})
+		}
+
+		// as in [Value.CompareAndSwap], compare again if the value was
+		// replaced in the meantime
+		if w.v.compareAndSwapBox(dp, box) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,73 @@
+//go:build go1.24
+
+package atomicval
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestWeakValue(t *testing.T) {
+	var a WeakValue[ex]
+	requireEqual(t, (*ex)(nil), a.Load())
+
+	x, y := &ex{a: 1}, &ex{a: 1}
+	requireEqual(t, false, a.CompareAndSwap(x, y))
+	requireEqual(t, true, a.CompareAndSwap(nil, x))
+	requireEqual(t, x, a.Load())
+
+	// compared by identity, not by the pointed-to value
+	requireEqual(t, false, a.CompareAndSwap(y, nil))
+	requireEqual(t, x, a.Swap(y))
+	requireEqual(t, y, a.Load())
+
+	a.Store(nil)
+	requireEqual(t, (*ex)(nil), a.Load())
+	requireEqual(t, (*ex)(nil), a.Swap(x))
+	requireEqual(t, true, a.CompareAndSwap(x, nil))
+	requireEqual(t, (*ex)(nil), a.Load())
+
+	// replaced by the same pointer: compared again, and swapped
+	a.Store(x)
+	interfere(t, &a.v, 1, func(int) { a.Store(x) })
+	requireEqual(t, true, a.CompareAndSwap(x, y))
+	requireEqual(t, y, a.Load())
+
+	runtime.KeepAlive(x)
+	runtime.KeepAlive(y)
+
+	t.Run("reclaimed", func(t *testing.T) {
+		var w WeakValue[[64]byte]
+		reclaimed := make(chan struct{})
+		func() {
+			p := new([64]byte)
+			runtime.AddCleanup(p, func(ch chan struct{}) { close(ch) }, reclaimed)
+			w.Store(p)
+			requireEqual(t, p, w.Load())
+		}()
+
+		runtime.GC()
+		<-reclaimed
+		requireEqual(t, (*[64]byte)(nil), w.Load())
+		requireEqual(t, (*[64]byte)(nil), w.Swap(nil))
+	})
+
+	t.Run("reclaimed compares as nil", func(t *testing.T) {
+		var w WeakValue[[64]byte]
+		w.Store(new([64]byte))
+		runtime.GC()
+
+		p := new([64]byte)
+		requireEqual(t, true, w.CompareAndSwap(nil, p))
+		requireEqual(t, p, w.Load())
+	})
+
+	t.Run("kept alive by strong references", func(t *testing.T) {
+		var w WeakValue[[64]byte]
+		p := new([64]byte)
+		w.Store(p)
+		runtime.GC()
+		requireEqual(t, p, w.Load())
+		runtime.KeepAlive(p)
+	})
+}